@@ -0,0 +1,38 @@
+package content
+
+import (
+	"bytes"
+)
+
+// Capture keeps a copy of what is written to it, up to a limit, for the
+// notifications that attach the image instead of linking to it
+type Capture struct {
+	limit  int64
+	buffer bytes.Buffer
+	over   bool
+}
+
+// NewCapture constructs a Capture that keeps at most limit bytes
+func NewCapture(limit int64) *Capture {
+	return &Capture{limit: limit}
+}
+
+func (capture *Capture) Write(p []byte) (int, error) {
+	if capture.over {
+		return len(p), nil
+	}
+	if int64(capture.buffer.Len())+int64(len(p)) > capture.limit {
+		capture.over = true
+		capture.buffer = bytes.Buffer{}
+		return len(p), nil
+	}
+	return capture.buffer.Write(p)
+}
+
+// Bytes returns the copy, or nil when more than the limit was written
+func (capture *Capture) Bytes() []byte {
+	if capture.over {
+		return nil
+	}
+	return capture.buffer.Bytes()
+}
@@ -0,0 +1,26 @@
+package content_test
+
+import (
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/content"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capture", func() {
+	It("Should keep what was written within the limit", func() {
+		capture := content.NewCapture(10)
+		capture.Write([]byte("hello"))
+		capture.Write([]byte("world"))
+		Expect(string(capture.Bytes())).To(Equal("helloworld"))
+	})
+
+	It("Should keep nothing once the limit is passed", func() {
+		capture := content.NewCapture(10)
+		capture.Write([]byte("hello"))
+		n, err := capture.Write([]byte("world!"))
+		Expect(n).To(Equal(6))
+		Expect(err).To(BeNil())
+		Expect(capture.Bytes()).To(BeNil())
+	})
+})
@@ -0,0 +1,140 @@
+package content
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+)
+
+// Stdin is the value of --content that reads the image from stdin
+const Stdin = "-"
+
+// dataURIPrefix starts a data URI such as data:image/png;base64,...
+const dataURIPrefix = "data:"
+
+// sniffLen is how much of stdin is looked at to tell base64 from an
+// image
+const sniffLen = 512
+
+// Open returns the image given as value, which is Stdin to read it from
+// stdin, a data URI, or base64. Stdin may hold the image itself, base64
+// or a data URI
+func Open(value string, stdin io.Reader) (io.Reader, error) {
+	if value == Stdin {
+		return openStream(stdin)
+	}
+
+	if hasDataURIPrefix(value) {
+		return openDataURI(bufio.NewReader(strings.NewReader(value)))
+	}
+	return uploader.NewBase64Decoder(strings.NewReader(value)), nil
+}
+
+// openStream tells from the start of the stream whether it is a data
+// URI, base64 or the image itself
+func openStream(stream io.Reader) (io.Reader, error) {
+	reader := bufio.NewReaderSize(stream, sniffLen)
+	head, err := reader.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if hasDataURIPrefix(string(head)) {
+		return openDataURI(reader)
+	}
+	if isBase64(head) {
+		return uploader.NewBase64Decoder(reader), nil
+	}
+	return reader, nil
+}
+
+// openDataURI reads the header of the data URI, up to the comma, and
+// returns a reader that decodes the rest as it is read
+func openDataURI(reader *bufio.Reader) (io.Reader, error) {
+	header, err := reader.ReadString(',')
+	if err == io.EOF {
+		return nil, fmt.Errorf("Invalid data URI, expected a comma after the media type")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	params := strings.Split(strings.TrimSuffix(header[len(dataURIPrefix):], ","), ";")
+	if params[len(params)-1] == "base64" {
+		return uploader.NewBase64Decoder(reader), nil
+	}
+
+	return &percentDecoder{source: reader}, nil
+}
+
+// percentDecoder decodes the percent encoded data of a data URI as it
+// is read
+type percentDecoder struct {
+	source *bufio.Reader
+}
+
+func (decoder *percentDecoder) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		char, err := decoder.source.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		if char == '%' {
+			char, err = decoder.unescape()
+			if err != nil {
+				return n, err
+			}
+		}
+		p[n] = char
+		n++
+
+		// return what is decoded rather than wait on a slow stdin
+		if decoder.source.Buffered() == 0 {
+			break
+		}
+	}
+	return n, nil
+}
+
+// unescape decodes the two hex digits after a %
+func (decoder *percentDecoder) unescape() (byte, error) {
+	escape := make([]byte, 2)
+	n, err := io.ReadFull(decoder.source, escape)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+
+	var char [1]byte
+	if _, decodeErr := hex.Decode(char[:], escape[:n]); n < len(escape) || decodeErr != nil {
+		return 0, fmt.Errorf("Invalid data URI: invalid URL escape %q", "%"+string(escape[:n]))
+	}
+	return char[0], nil
+}
+
+func hasDataURIPrefix(value string) bool {
+	return len(value) >= len(dataURIPrefix) && strings.EqualFold(value[:len(dataURIPrefix)], dataURIPrefix)
+}
+
+// isBase64 is true when head is not empty and every byte of it may be
+// part of base64. Every image format starts with bytes that are not
+func isBase64(head []byte) bool {
+	trimmed := bytes.TrimSpace(head)
+	if len(trimmed) == 0 {
+		return false
+	}
+
+	for _, b := range trimmed {
+		isAlphabet := b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || strings.IndexByte("+/-_=", b) >= 0
+		isSpace := strings.IndexByte(" \t\r\n\f\v", b) >= 0
+		if !isAlphabet && !isSpace {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package content_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/content"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Content", func() {
+	var png []byte
+	var encoded string
+
+	BeforeEach(func() {
+		png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+		encoded = base64.StdEncoding.EncodeToString(png)
+	})
+
+	read := func(value, stdin string) ([]byte, error) {
+		reader, err := content.Open(value, strings.NewReader(stdin))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(reader)
+	}
+
+	Describe("content.Open(base64, stdin)", func() {
+		It("Should decode the base64", func() {
+			data, err := read(encoded, "")
+			Expect(err).To(BeNil())
+			Expect(data).To(Equal(png))
+		})
+
+		It("Should have an error when it is not base64", func() {
+			_, err := read("not base64!", "")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("content.Open(dataURI, stdin)", func() {
+		It("Should decode a base64 data URI", func() {
+			data, err := read("data:image/png;base64,"+encoded, "")
+			Expect(err).To(BeNil())
+			Expect(data).To(Equal(png))
+		})
+
+		It("Should decode a percent encoded data URI", func() {
+			data, err := read("data:image/svg+xml,%3Csvg%2F%3E", "")
+			Expect(err).To(BeNil())
+			Expect(string(data)).To(Equal("<svg/>"))
+		})
+
+		It("Should have an error for an invalid percent escape", func() {
+			_, err := read("data:image/svg+xml,%3Csvg%2", "")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(`Invalid data URI: invalid URL escape "%2"`))
+		})
+
+		It("Should have an error when there is no comma", func() {
+			_, err := read("data:image/png;base64", "")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("Invalid data URI, expected a comma after the media type"))
+		})
+	})
+
+	Describe("content.Open(content.Stdin, stdin)", func() {
+		It("Should read an image as it is", func() {
+			data, err := read(content.Stdin, string(png))
+			Expect(err).To(BeNil())
+			Expect(data).To(Equal(png))
+		})
+
+		It("Should decode base64 with a trailing newline", func() {
+			data, err := read(content.Stdin, encoded+"\n")
+			Expect(err).To(BeNil())
+			Expect(data).To(Equal(png))
+		})
+
+		It("Should decode a data URI", func() {
+			data, err := read(content.Stdin, "data:image/png;base64,"+encoded)
+			Expect(err).To(BeNil())
+			Expect(data).To(Equal(png))
+		})
+
+		It("Should read an image longer than what is looked at", func() {
+			large := append(png, make([]byte, 4096)...)
+			data, err := read(content.Stdin, string(large))
+			Expect(err).To(BeNil())
+			Expect(data).To(Equal(large))
+		})
+
+		It("Should read nothing from an empty stdin", func() {
+			data, err := read(content.Stdin, "")
+			Expect(err).To(BeNil())
+			Expect(data).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,201 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// Discord posts to a discord channel through a webhook
+type Discord interface {
+	// Notify posts an embed built from the notification
+	notifier.Notifier
+
+	// Execute posts the payload, with the files attached
+	Execute(payload *Payload, files ...*File) error
+}
+
+// Options configures the behavior of a Discord instance
+type Options struct {
+	// HTTPOptions.Retry also waits for discord's rate limit bucket to
+	// refill before sending, once discord has said it is empty
+	notifier.HTTPOptions
+
+	// AttachImage makes Notify upload the image with the message,
+	// instead of linking to it
+	AttachImage bool
+
+	// Color is the color of the embed's left border, as 0xRRGGBB
+	Color int
+
+	// Username and AvatarURL override how the webhook's messages appear
+	Username  string
+	AvatarURL string
+}
+
+// File is a file attached to a message
+type File struct {
+	Name    string
+	Content []byte
+}
+
+type webhookDiscord struct {
+	webhookURI string
+	options    Options
+
+	// mutex guards resetAt, when the rate limit bucket refills once
+	// discord has said it is empty
+	mutex   sync.Mutex
+	resetAt time.Time
+}
+
+// New constructs a new discord instance using a webhook
+func New(webhookURI string, options Options) Discord {
+	options.HTTPOptions = options.HTTPOptions.WithDefaults()
+	if options.Retry.Sleep == nil {
+		options.Retry.Sleep = time.Sleep
+	}
+	return &webhookDiscord{webhookURI: webhookURI, options: options}
+}
+
+func (discord *webhookDiscord) Notify(notification *notifier.Notification) error {
+	payload := NewNotificationPayload(notification, discord.options.Color)
+	payload.Username = discord.options.Username
+	payload.AvatarURL = discord.options.AvatarURL
+
+	if !discord.options.AttachImage || len(notification.Image) == 0 {
+		return discord.Execute(payload)
+	}
+
+	file := &File{Name: notification.Filename, Content: notification.Image}
+	for _, embed := range payload.Embeds {
+		embed.Image = &EmbedImage{URL: "attachment://" + file.Name}
+	}
+	return discord.Execute(payload, file)
+}
+
+func (discord *webhookDiscord) Execute(payload *Payload, files ...*File) error {
+	body, contentType, err := encode(payload, files)
+	if err != nil {
+		return err
+	}
+
+	return discord.options.Retry.Do(func() error {
+		discord.waitForReset()
+
+		request, err := http.NewRequest("POST", discord.webhookURI, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Content-Type", contentType)
+
+		return discord.do(request)
+	})
+}
+
+// do sends the request like notifier.Do, also keeping track of the rate
+// limit headers
+func (discord *webhookDiscord) do(request *http.Request) error {
+	resp, err := discord.options.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	discord.trackRateLimit(resp.Header)
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	statusError := notifier.NewStatusError(resp, body)
+	statusError.RetryAfter = retryAfter(resp.Header, body)
+	return statusError
+}
+
+// trackRateLimit remembers when the bucket refills once discord says no
+// requests remain in it
+func (discord *webhookDiscord) trackRateLimit(header http.Header) {
+	if header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+
+	resetAfter := notifier.ParseRetryAfter(header.Get("X-RateLimit-Reset-After"))
+	discord.mutex.Lock()
+	discord.resetAt = time.Now().Add(resetAfter)
+	discord.mutex.Unlock()
+}
+
+// waitForReset sleeps until the bucket refills, when it is empty
+func (discord *webhookDiscord) waitForReset() {
+	discord.mutex.Lock()
+	wait := time.Until(discord.resetAt)
+	discord.mutex.Unlock()
+
+	if wait > 0 {
+		discord.options.Retry.Sleep(wait)
+	}
+}
+
+// retryAfter is how long discord asked to wait after a rate limit. It
+// prefers the precise reset header, then Retry-After, then the body
+func retryAfter(header http.Header, body []byte) time.Duration {
+	if resetAfter := notifier.ParseRetryAfter(header.Get("X-RateLimit-Reset-After")); resetAfter > 0 {
+		return resetAfter
+	}
+	if retryAfter := notifier.ParseRetryAfter(header.Get("Retry-After")); retryAfter > 0 {
+		return retryAfter
+	}
+
+	var rateLimit struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if json.Unmarshal(body, &rateLimit) == nil && rateLimit.RetryAfter > 0 {
+		return time.Duration(rateLimit.RetryAfter * float64(time.Second))
+	}
+	return 0
+}
+
+// encode returns the payload as JSON, or as multipart form data with a
+// payload_json part when there are files to attach
+func encode(payload *Payload, files []*File) ([]byte, string, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(files) == 0 {
+		return payloadBytes, "application/json", nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	err = writer.WriteField("payload_json", string(payloadBytes))
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i, file := range files {
+		part, err := writer.CreateFormFile("files["+strconv.Itoa(i)+"]", file.Name)
+		if err != nil {
+			return nil, "", err
+		}
+		_, err = part.Write(file.Content)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
@@ -0,0 +1,13 @@
+package discord_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDiscord(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Discord Suite")
+}
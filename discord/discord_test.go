@@ -0,0 +1,204 @@
+package discord_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/discord"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type request struct {
+	contentType string
+	body        string
+	payloadJSON string
+	fileName    string
+	fileContent string
+}
+
+var _ = Describe("Discord", func() {
+	var sut discord.Discord
+	var server *httptest.Server
+	var handler http.HandlerFunc
+	var requests []request
+	var sleeps []time.Duration
+	var options discord.Options
+	var err error
+
+	notification := &notifier.Notification{
+		Title:     "render.png",
+		Text:      "Nightly render",
+		URL:       "https://dropbox.biz/render.png",
+		ImageURL:  "https://dropbox.biz/render.png?raw=1",
+		Image:     []byte("png-bytes"),
+		Filename:  "render.png",
+		Facts:     []notifier.Fact{{Name: "Build", Value: "42"}},
+		Timestamp: time.Date(2016, 7, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	BeforeEach(func() {
+		requests = nil
+		sleeps = nil
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorded := request{contentType: r.Header.Get("Content-Type")}
+			if strings.HasPrefix(recorded.contentType, "multipart/form-data") {
+				r.ParseMultipartForm(1 << 20)
+				recorded.payloadJSON = r.FormValue("payload_json")
+				file, header, _ := r.FormFile("files[0]")
+				content, _ := ioutil.ReadAll(file)
+				recorded.fileName = header.Filename
+				recorded.fileContent = string(content)
+			} else {
+				body, _ := ioutil.ReadAll(r.Body)
+				recorded.body = string(body)
+			}
+			requests = append(requests, recorded)
+			handler(w, r)
+		}))
+
+		policy := retry.Policy{
+			MaxAttempts: 3,
+			Sleep: func(duration time.Duration) {
+				sleeps = append(sleeps, duration)
+			},
+		}
+		options = discord.Options{Color: 0x2ecc71, HTTPOptions: notifier.HTTPOptions{Retry: policy}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		sut = discord.New(server.URL, options)
+		err = sut.Notify(notification)
+	})
+
+	Describe("sut.Notify(notification)", func() {
+		It("Should have posted an embed as JSON", func() {
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].contentType).To(Equal("application/json"))
+			Expect(requests[0].body).To(MatchJSON(`{
+				"embeds": [{
+					"title": "render.png",
+					"description": "Nightly render",
+					"url": "https://dropbox.biz/render.png",
+					"color": 3066993,
+					"timestamp": "2016-07-01T12:00:00Z",
+					"image": {"url": "https://dropbox.biz/render.png?raw=1"},
+					"fields": [{"name": "Build", "value": "42", "inline": true}]
+				}]
+			}`))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when attaching the image", func() {
+		BeforeEach(func() {
+			options.AttachImage = true
+			options.Username = "renders"
+		})
+
+		It("Should have sent the image as files[0]", func() {
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].fileName).To(Equal("render.png"))
+			Expect(requests[0].fileContent).To(Equal("png-bytes"))
+		})
+
+		It("Should have pointed the embed at the attachment", func() {
+			Expect(requests[0].payloadJSON).To(ContainSubstring(`"image":{"url":"attachment://render.png"}`))
+			Expect(requests[0].payloadJSON).To(ContainSubstring(`"username":"renders"`))
+		})
+	})
+
+	Describe("when the notification has mentions", func() {
+		var withoutMentions *notifier.Notification
+
+		BeforeEach(func() {
+			withoutMentions = notification
+			withMentions := *notification
+			withMentions.Mentions = []string{"<@&123456>"}
+			notification = &withMentions
+		})
+
+		AfterEach(func() {
+			notification = withoutMentions
+		})
+
+		It("Should have put the mentions in the content", func() {
+			Expect(requests[0].body).To(ContainSubstring(`"content":"\u003c@\u0026123456\u003e"`))
+		})
+	})
+
+	Describe("when discord rate limits the first request", func() {
+		BeforeEach(func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if len(requests) > 1 {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.Header().Set("X-RateLimit-Reset-After", "1.5")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 1.5}`))
+			}
+		})
+
+		It("Should have waited as long as discord asked and posted again", func() {
+			Expect(requests).To(HaveLen(2))
+			Expect(sleeps).To(Equal([]time.Duration{1500 * time.Millisecond}))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when discord rate limits with only the body", func() {
+		BeforeEach(func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if len(requests) > 1 {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 0.25}`))
+			}
+		})
+
+		It("Should have waited for the body's retry_after", func() {
+			Expect(sleeps).To(Equal([]time.Duration{250 * time.Millisecond}))
+		})
+	})
+
+	Describe("when discord rejects the payload", func() {
+		BeforeEach(func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"message": "Invalid Form Body", "code": 50035}`))
+			}
+		})
+
+		It("Should not have retried", func() {
+			Expect(requests).To(HaveLen(1))
+		})
+
+		It("Should have a StatusError with discord's message", func() {
+			Expect(err).To(BeAssignableToTypeOf(&notifier.StatusError{}))
+			Expect(err.Error()).To(ContainSubstring("Invalid Form Body"))
+		})
+	})
+})
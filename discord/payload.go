@@ -0,0 +1,64 @@
+package discord
+
+import (
+	"strings"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// Payload is the message posted to a discord webhook
+type Payload struct {
+	Content   string   `json:"content,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	AvatarURL string   `json:"avatar_url,omitempty"`
+	Embeds    []*Embed `json:"embeds,omitempty"`
+}
+
+// Embed is a rich preview shown below the content. Timestamp is in
+// ISO8601
+type Embed struct {
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	Color       int           `json:"color,omitempty"`
+	Timestamp   string        `json:"timestamp,omitempty"`
+	Image       *EmbedImage   `json:"image,omitempty"`
+	Fields      []*EmbedField `json:"fields,omitempty"`
+}
+
+// EmbedImage is the image shown in an embed. The URL must serve the image
+// itself, or be attachment://<name> for an attached file
+type EmbedImage struct {
+	URL string `json:"url"`
+}
+
+// EmbedField is a name and value pair shown in an embed
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// NewNotificationPayload constructs a payload with an embed showing the
+// notification's title, link, text, image and facts
+func NewNotificationPayload(notification *notifier.Notification, color int) *Payload {
+	embed := &Embed{
+		Title:       notification.Title,
+		Description: notification.Text,
+		URL:         notification.URL,
+		Color:       color,
+	}
+	if !notification.Timestamp.IsZero() {
+		embed.Timestamp = notification.Timestamp.UTC().Format(time.RFC3339)
+	}
+	if notification.ImageURL != "" {
+		embed.Image = &EmbedImage{URL: notification.ImageURL}
+	}
+	for _, fact := range notification.Facts {
+		embed.Fields = append(embed.Fields, &EmbedField{Name: fact.Name, Value: fact.Value, Inline: true})
+	}
+
+	// discord only notifies the mentions in the content, not in embeds
+	return &Payload{Content: strings.Join(notification.Mentions, " "), Embeds: []*Embed{embed}}
+}
@@ -0,0 +1,193 @@
+package email
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// DefaultTimeout limits connecting to the SMTP server when no Timeout is
+// given
+const DefaultTimeout = 30 * time.Second
+
+// StartTLS policies, deciding whether the connection is upgraded to TLS
+// before authenticating and sending
+const (
+	// StartTLSOpportunistic upgrades when the server offers STARTTLS
+	StartTLSOpportunistic = ""
+
+	// StartTLSRequired fails when the server does not offer STARTTLS
+	StartTLSRequired = "required"
+
+	// StartTLSDisabled never upgrades
+	StartTLSDisabled = "disabled"
+)
+
+// Email sends notifications by email through an SMTP server
+type Email interface {
+	// Notify emails the notification, with its image shown inline
+	notifier.Notifier
+}
+
+// Options configures the behavior of an Email instance
+type Options struct {
+	// Host and Port of the SMTP server. Port defaults to 587, or 465
+	// with ImplicitTLS
+	Host string
+	Port int
+
+	// Username and Password authenticate with PLAIN auth when Username
+	// is not empty
+	Username string
+	Password string
+
+	// From is the sender's address, and To the recipients
+	From string
+	To   []string
+
+	// Subject defaults to the notification's title
+	Subject string
+
+	// StartTLS is one of the StartTLS policies
+	StartTLS string
+
+	// ImplicitTLS connects with TLS from the start, as on port 465
+	ImplicitTLS bool
+
+	// TLSConfig is used for STARTTLS and ImplicitTLS. It defaults to
+	// verifying the Host
+	TLSConfig *tls.Config
+
+	// Timeout limits connecting to the server. It defaults to
+	// DefaultTimeout
+	Timeout time.Duration
+}
+
+type smtpEmail struct {
+	options Options
+}
+
+// New constructs a new email instance that sends through the SMTP server
+func New(options Options) (Email, error) {
+	if options.Host == "" {
+		return nil, fmt.Errorf("Missing the SMTP host")
+	}
+	if options.From == "" || len(options.To) == 0 {
+		return nil, fmt.Errorf("Email needs a From address and at least one To address")
+	}
+	switch options.StartTLS {
+	case StartTLSOpportunistic, StartTLSRequired, StartTLSDisabled:
+	default:
+		return nil, fmt.Errorf("Invalid StartTLS policy: %v, expected required or disabled", options.StartTLS)
+	}
+
+	if options.Port == 0 {
+		options.Port = 587
+		if options.ImplicitTLS {
+			options.Port = 465
+		}
+	}
+	if options.TLSConfig == nil {
+		options.TLSConfig = &tls.Config{ServerName: options.Host}
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DefaultTimeout
+	}
+	return &smtpEmail{options}, nil
+}
+
+func (email *smtpEmail) Notify(notification *notifier.Notification) error {
+	subject := email.options.Subject
+	if subject == "" {
+		subject = notification.Title
+	}
+
+	message, err := NewMessage(email.options.From, email.options.To, subject, notification, time.Now())
+	if err != nil {
+		return err
+	}
+	return email.send(message)
+}
+
+// send delivers the message over a new connection to the server
+func (email *smtpEmail) send(message []byte) error {
+	client, err := email.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if !email.options.ImplicitTLS && email.options.StartTLS != StartTLSDisabled {
+		ok, _ := client.Extension("STARTTLS")
+		if ok {
+			err = client.StartTLS(email.options.TLSConfig)
+			if err != nil {
+				return err
+			}
+		} else if email.options.StartTLS == StartTLSRequired {
+			return fmt.Errorf("SMTP server %v does not offer STARTTLS", email.options.Host)
+		}
+	}
+
+	if email.options.Username != "" {
+		auth := smtp.PlainAuth("", email.options.Username, email.options.Password, email.options.Host)
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(email.options.From)
+	if err != nil {
+		return err
+	}
+	for _, to := range email.options.To {
+		err = client.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(message)
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (email *smtpEmail) dial() (*smtp.Client, error) {
+	address := net.JoinHostPort(email.options.Host, strconv.Itoa(email.options.Port))
+	dialer := &net.Dialer{Timeout: email.options.Timeout}
+
+	var conn net.Conn
+	var err error
+	if email.options.ImplicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, email.options.TLSConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, email.options.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
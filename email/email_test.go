@@ -0,0 +1,183 @@
+package email_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/email"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Email", func() {
+	var sut email.Email
+	var server *smtpServer
+	var options email.Options
+	var notification *notifier.Notification
+	var err error
+
+	BeforeEach(func() {
+		notification = &notifier.Notification{
+			Title:    "example.png",
+			URL:      "https://dropbox.biz/example.png",
+			Image:    []byte("\x89PNG\r\n\x1a\nnot-really-a-png"),
+			Filename: "example.png",
+			Facts:    []notifier.Fact{{Name: "Job", Value: "ios-smoke"}},
+		}
+		server = newSMTPServer("AUTH PLAIN")
+		options = email.Options{
+			Host:     "127.0.0.1",
+			Port:     server.port(),
+			Username: "uploader",
+			Password: "hunter2",
+			From:     "uploader@example.com",
+			To:       []string{"stakeholder@example.com", "lead@example.com"},
+			Timeout:  time.Second,
+		}
+	})
+
+	AfterEach(func() {
+		server.close()
+	})
+
+	JustBeforeEach(func() {
+		var newErr error
+		sut, newErr = email.New(options)
+		Expect(newErr).To(BeNil())
+		err = sut.Notify(notification)
+	})
+
+	Describe("when the server accepts the message", func() {
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+
+		It("Should have authenticated with PLAIN", func() {
+			plain := base64.StdEncoding.EncodeToString([]byte("\x00uploader\x00hunter2"))
+			Expect(server.auths).To(Equal([]string{"AUTH PLAIN " + plain}))
+		})
+
+		It("Should have sent to every recipient", func() {
+			Expect(server.mailFrom).To(Equal([]string{"MAIL FROM:<uploader@example.com>"}))
+			Expect(server.rcptTo).To(Equal([]string{"RCPT TO:<stakeholder@example.com>", "RCPT TO:<lead@example.com>"}))
+		})
+
+		It("Should have sent a plain text part and an HTML part with the inline image", func() {
+			Expect(server.data).To(HaveLen(1))
+			message, parseErr := mail.ReadMessage(reader(server.data[0]))
+			Expect(parseErr).To(BeNil())
+			Expect(message.Header.Get("Subject")).To(Equal("example.png"))
+
+			mediaType, params, _ := mime.ParseMediaType(message.Header.Get("Content-Type"))
+			Expect(mediaType).To(Equal("multipart/alternative"))
+			alternative := multipart.NewReader(message.Body, params["boundary"])
+
+			plain, _ := alternative.NextPart()
+			Expect(plain.Header.Get("Content-Type")).To(Equal("text/plain; charset=utf-8"))
+			plainBody, _ := ioutil.ReadAll(plain)
+			Expect(string(plainBody)).To(ContainSubstring("https://dropbox.biz/example.png"))
+			Expect(string(plainBody)).To(ContainSubstring("Job: ios-smoke"))
+
+			related, _ := alternative.NextPart()
+			mediaType, params, _ = mime.ParseMediaType(related.Header.Get("Content-Type"))
+			Expect(mediaType).To(Equal("multipart/related"))
+			relatedReader := multipart.NewReader(related, params["boundary"])
+
+			html, _ := relatedReader.NextPart()
+			Expect(html.Header.Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+			htmlBody, _ := ioutil.ReadAll(html)
+			Expect(string(htmlBody)).To(ContainSubstring(`<img src="cid:upload-image" alt="example.png">`))
+			Expect(string(htmlBody)).To(ContainSubstring(`<a href="https://dropbox.biz/example.png">`))
+
+			image, _ := relatedReader.NextPart()
+			Expect(image.Header.Get("Content-Type")).To(Equal("image/png"))
+			Expect(image.Header.Get("Content-ID")).To(Equal("<upload-image>"))
+			imageBody, _ := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, image))
+			Expect(imageBody).To(Equal(notification.Image))
+		})
+	})
+
+	Describe("with text", func() {
+		BeforeEach(func() {
+			notification.Text = "Saved /builds/<ios>/a&b.png"
+		})
+
+		It("Should have escaped the text once for HTML", func() {
+			Expect(err).To(BeNil())
+			Expect(server.data[0]).To(ContainSubstring("Saved /builds/&lt;ios&gt;/a&amp;b.png"))
+			Expect(server.data[0]).To(ContainSubstring("Saved /builds/<ios>/a&b.png"))
+			Expect(server.data[0]).NotTo(ContainSubstring("&amp;lt;"))
+		})
+	})
+
+	Describe("with an SVG image", func() {
+		BeforeEach(func() {
+			notification.Image = []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"></svg>`)
+		})
+
+		It("Should have sent the image as image/svg+xml", func() {
+			Expect(err).To(BeNil())
+			Expect(sentImageContentType(server.data[0])).To(Equal("image/svg+xml"))
+		})
+	})
+
+	Describe("with content that is not a recognized image", func() {
+		BeforeEach(func() {
+			notification.Image = []byte("GIF87 is not quite a gif")
+		})
+
+		It("Should have sent it as application/octet-stream", func() {
+			Expect(err).To(BeNil())
+			Expect(sentImageContentType(server.data[0])).To(Equal("application/octet-stream"))
+		})
+	})
+
+	Describe("when STARTTLS is required but not offered", func() {
+		BeforeEach(func() {
+			options.StartTLS = email.StartTLSRequired
+		})
+
+		It("Should have an error", func() {
+			Expect(err).To(MatchError("SMTP server 127.0.0.1 does not offer STARTTLS"))
+		})
+
+		It("Should not have sent anything", func() {
+			Expect(server.data).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("New", func() {
+	It("Should require recipients", func() {
+		_, err := email.New(email.Options{Host: "localhost", From: "uploader@example.com"})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Should reject an unknown StartTLS policy", func() {
+		_, err := email.New(email.Options{Host: "localhost", From: "a@example.com", To: []string{"b@example.com"}, StartTLS: "sometimes"})
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+// sentImageContentType returns the Content-Type of the inline image in
+// the message data
+func sentImageContentType(data string) string {
+	message, err := mail.ReadMessage(reader(data))
+	Expect(err).To(BeNil())
+
+	_, params, _ := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	alternative := multipart.NewReader(message.Body, params["boundary"])
+	alternative.NextPart()
+	related, _ := alternative.NextPart()
+	_, params, _ = mime.ParseMediaType(related.Header.Get("Content-Type"))
+	relatedReader := multipart.NewReader(related, params["boundary"])
+	relatedReader.NextPart()
+	image, _ := relatedReader.NextPart()
+	return image.Header.Get("Content-Type")
+}
@@ -0,0 +1,169 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+)
+
+// imageContentID is how the HTML part refers to the inline image
+const imageContentID = "upload-image"
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body>
+{{- if .Text}}
+<p>{{.Text}}</p>
+{{- end}}
+{{- if .Image}}
+<p><a href="{{.URL}}"><img src="cid:{{.ContentID}}" alt="{{.Title}}"></a></p>
+{{- end}}
+<p><a href="{{.URL}}">{{.Title}}</a></p>
+{{- if .Facts}}
+<table>
+{{- range .Facts}}
+<tr><th align="left">{{.Name}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// NewMessage builds the email for the notification: a plain text part,
+// and an HTML part with the image attached inline by its Content-ID
+func NewMessage(from string, to []string, subject string, notification *notifier.Notification, now time.Time) ([]byte, error) {
+	var message bytes.Buffer
+	alternative := multipart.NewWriter(&message)
+
+	fmt.Fprintf(&message, "From: %v\r\n", from)
+	fmt.Fprintf(&message, "To: %v\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %v\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%v\r\n\r\n", alternative.Boundary())
+
+	err := writeQuotedPrintable(alternative, "text/plain; charset=utf-8", plainText(notification))
+	if err != nil {
+		return nil, err
+	}
+
+	var related bytes.Buffer
+	relatedWriter := multipart.NewWriter(&related)
+	html, err := htmlText(notification)
+	if err != nil {
+		return nil, err
+	}
+	err = writeQuotedPrintable(relatedWriter, "text/html; charset=utf-8", html)
+	if err != nil {
+		return nil, err
+	}
+	if len(notification.Image) > 0 {
+		err = writeImage(relatedWriter, notification)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = relatedWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	relatedHeader := textproto.MIMEHeader{}
+	relatedHeader.Set("Content-Type", fmt.Sprintf("multipart/related; boundary=%v", relatedWriter.Boundary()))
+	part, err := alternative.CreatePart(relatedHeader)
+	if err != nil {
+		return nil, err
+	}
+	_, err = part.Write(related.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	err = alternative.Close()
+	if err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+func plainText(notification *notifier.Notification) string {
+	var text strings.Builder
+	if notification.Text != "" {
+		fmt.Fprintf(&text, "%v\r\n\r\n", notification.Text)
+	}
+	fmt.Fprintf(&text, "%v\r\n%v\r\n", notification.Title, notification.URL)
+	for _, fact := range notification.Facts {
+		fmt.Fprintf(&text, "\r\n%v: %v", fact.Name, fact.Value)
+	}
+	return text.String()
+}
+
+func htmlText(notification *notifier.Notification) (string, error) {
+	var html strings.Builder
+	err := htmlTemplate.Execute(&html, struct {
+		*notifier.Notification
+		ContentID string
+	}{notification, imageContentID})
+	return html.String(), err
+}
+
+func writeQuotedPrintable(writer *multipart.Writer, contentType, text string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoder := quotedprintable.NewWriter(part)
+	_, err = encoder.Write([]byte(text))
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func writeImage(writer *multipart.Writer, notification *notifier.Notification) error {
+	filename := notification.Filename
+	if filename == "" {
+		filename = "image"
+	}
+
+	contentType := uploader.SniffContentType(notification.Image).MIMEType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-ID", "<"+imageContentID+">")
+	header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(notification.Image)
+	for len(encoded) > 76 {
+		_, err = fmt.Fprintf(part, "%v\r\n", encoded[:76])
+		if err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%v\r\n", encoded)
+	return err
+}
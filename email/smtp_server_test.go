@@ -0,0 +1,98 @@
+package email_test
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+// smtpServer is a local SMTP stand-in that accepts one message per
+// connection and records the conversation
+type smtpServer struct {
+	listener   net.Listener
+	extensions []string
+	auths      []string
+	mailFrom   []string
+	rcptTo     []string
+	data       []string
+}
+
+func newSMTPServer(extensions ...string) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	server := &smtpServer{listener: listener, extensions: extensions}
+	go server.serve()
+	return server
+}
+
+func (server *smtpServer) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *smtpServer) close() {
+	server.listener.Close()
+}
+
+func (server *smtpServer) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		server.handle(conn)
+	}
+}
+
+func (server *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP stand-in")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			lines := append([]string{"localhost"}, server.extensions...)
+			for i, extension := range lines {
+				separator := "-"
+				if i == len(lines)-1 {
+					separator = " "
+				}
+				text.PrintfLine("250%v%v", separator, extension)
+			}
+		case "AUTH":
+			server.auths = append(server.auths, line)
+			text.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			server.mailFrom = append(server.mailFrom, line)
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			server.rcptTo = append(server.rcptTo, line)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			lines, _ := text.ReadDotLines()
+			server.data = append(server.data, strings.Join(lines, "\r\n"))
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// reader is used by tests to parse the recorded message
+func reader(data string) *bufio.Reader {
+	return bufio.NewReader(strings.NewReader(data + "\r\n"))
+}
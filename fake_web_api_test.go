@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+)
+
+// FakeWebAPI records the messages posted to it, and posts each one at
+// the next of PostedTS
+type FakeWebAPI struct {
+	PostedTS []string
+	Posted   []*slack.Message
+
+	UpdateErr error
+	Updated   []string
+}
+
+func (fake *FakeWebAPI) Notify(notification *notifier.Notification) error {
+	return nil
+}
+
+func (fake *FakeWebAPI) Post(text string) error {
+	return fake.PostMessage(&slack.Message{Text: text})
+}
+
+func (fake *FakeWebAPI) PostImage(text string, image slack.Image) error {
+	return fake.PostMessage(&slack.Message{Text: text})
+}
+
+func (fake *FakeWebAPI) PostMessage(message *slack.Message) error {
+	_, err := fake.PostMessageWithResult(message)
+	return err
+}
+
+func (fake *FakeWebAPI) PostMessageWithResult(message *slack.Message) (*slack.PostResult, error) {
+	ts := fake.PostedTS[len(fake.Posted)]
+	fake.Posted = append(fake.Posted, message)
+	return &slack.PostResult{Channel: "C123", TS: ts}, nil
+}
+
+func (fake *FakeWebAPI) UpdateMessage(channel, ts string, message *slack.Message) (*slack.PostResult, error) {
+	fake.Updated = append(fake.Updated, channel+"/"+ts)
+	if fake.UpdateErr != nil {
+		return nil, fake.UpdateErr
+	}
+	return &slack.PostResult{Channel: channel, TS: ts}, nil
+}
+
+func (fake *FakeWebAPI) UploadFile(file *slack.File) (*slack.FileResult, error) {
+	return &slack.FileResult{}, nil
+}
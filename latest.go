@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/state"
+)
+
+// latestMessages keeps the message posted for each dropbox path, so
+// --update-latest can edit it instead of posting again
+type latestMessages struct {
+	store *state.Store
+}
+
+// getLatestMessages returns the message store for --update-latest, or
+// nil when it was not given
+func getLatestMessages(context *cli.Context) (*latestMessages, error) {
+	if !context.Bool("update-latest") {
+		return nil, nil
+	}
+	if context.String("slack-bot-token") == "" {
+		return nil, fmt.Errorf("--update-latest requires --slack-bot-token")
+	}
+	if context.String("slack-upload") == slackUploadInstead {
+		return nil, fmt.Errorf("--update-latest cannot update a message posted with --slack-upload instead")
+	}
+
+	path, err := stateFile(context.String("latest-state-file"), "latest.json")
+	if err != nil {
+		return nil, err
+	}
+	return &latestMessages{state.New(path)}, nil
+}
+
+// post updates the message posted for the dropbox path, or posts a new
+// one when there is none or it was deleted
+func (latest *latestMessages) post(webAPI slack.WebAPI, dropboxPath string, message *slack.Message) (*slack.PostResult, error) {
+	var previous postedMessage
+	ok, err := latest.store.Get(dropboxPath, &previous)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		updated, err := webAPI.UpdateMessage(previous.Channel, previous.TS, message)
+		if err == nil {
+			debug("updated message %v in channel %v", updated.TS, updated.Channel)
+			return updated, nil
+		}
+		if !slack.IsMessageNotFound(err) {
+			return nil, err
+		}
+		debug("message %v was deleted, posting a new one", previous.TS)
+	}
+
+	posted, err := postMessage(webAPI, message)
+	if err != nil {
+		return nil, err
+	}
+
+	err = latest.store.Set(dropboxPath, postedMessage{Channel: posted.Channel, TS: posted.TS})
+	if err != nil {
+		return nil, err
+	}
+	return posted, nil
+}
+
+// withUpdatedAt adds when the message was last updated below it, shown in
+// the reader's timezone
+func withUpdatedAt(message *slack.Message, now time.Time) *slack.Message {
+	blocks := append([]slack.Block{}, message.Blocks...)
+	if len(blocks) == 0 {
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewText(message.Text)))
+	}
+
+	fallback := now.UTC().Format(time.RFC1123)
+	updatedAt := fmt.Sprintf("Updated <!date^%v^{date_short_pretty} at {time}|%v>", now.Unix(), fallback)
+	blocks = append(blocks, slack.NewContextBlock(slack.NewText(updatedAt)))
+
+	updated := *message
+	updated.Blocks = blocks
+	return &updated
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/state"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("latestMessages", func() {
+	var dir string
+	var webAPI *FakeWebAPI
+	var latest *latestMessages
+	var posted *slack.PostResult
+	var err error
+
+	BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "latest")
+		Expect(err).To(BeNil())
+
+		webAPI = &FakeWebAPI{PostedTS: []string{"2.000200"}}
+		latest = &latestMessages{state.New(filepath.Join(dir, "latest.json"))}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		posted, err = latest.post(webAPI, "/nightly.png", &slack.Message{Text: "nightly"})
+	})
+
+	Describe("when nothing was posted for the path", func() {
+		It("Should post a new message", func() {
+			Expect(err).To(BeNil())
+			Expect(webAPI.Updated).To(BeEmpty())
+			Expect(posted).To(Equal(&slack.PostResult{Channel: "C123", TS: "2.000200"}))
+		})
+
+		It("Should save the message for the path", func() {
+			var saved postedMessage
+			Expect(latest.store.Get("/nightly.png", &saved)).To(BeTrue())
+			Expect(saved).To(Equal(postedMessage{Channel: "C123", TS: "2.000200"}))
+		})
+	})
+
+	Describe("when a message was posted for the path", func() {
+		BeforeEach(func() {
+			Expect(latest.store.Set("/nightly.png", postedMessage{Channel: "C123", TS: "1.000100"})).To(Succeed())
+		})
+
+		It("Should update it", func() {
+			Expect(err).To(BeNil())
+			Expect(webAPI.Updated).To(Equal([]string{"C123/1.000100"}))
+			Expect(webAPI.Posted).To(BeEmpty())
+			Expect(posted.TS).To(Equal("1.000100"))
+		})
+
+		Describe("when it was deleted", func() {
+			BeforeEach(func() {
+				webAPI.UpdateErr = &slack.APIError{Method: "chat.update", Code: "message_not_found"}
+			})
+
+			It("Should post a new message", func() {
+				Expect(err).To(BeNil())
+				Expect(webAPI.Updated).To(Equal([]string{"C123/1.000100"}))
+				Expect(webAPI.Posted).To(HaveLen(1))
+				Expect(posted.TS).To(Equal("2.000200"))
+			})
+
+			It("Should save the new message for the path", func() {
+				var saved postedMessage
+				Expect(latest.store.Get("/nightly.png", &saved)).To(BeTrue())
+				Expect(saved).To(Equal(postedMessage{Channel: "C123", TS: "2.000200"}))
+			})
+		})
+
+		Describe("when the update fails otherwise", func() {
+			BeforeEach(func() {
+				webAPI.UpdateErr = &slack.APIError{Method: "chat.update", Code: "cant_update_message"}
+			})
+
+			It("Should return the error without posting", func() {
+				Expect(err).To(MatchError("Slack chat.update failed: cant_update_message"))
+				Expect(webAPI.Posted).To(BeEmpty())
+			})
+		})
+	})
+})
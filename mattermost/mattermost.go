@@ -0,0 +1,142 @@
+package mattermost
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// Mattermost posts to a mattermost channel through an incoming webhook
+type Mattermost interface {
+	// Notify posts a message built from the notification
+	notifier.Notifier
+
+	// PostMessage posts the message, applying the overrides from the
+	// options where the message has none
+	PostMessage(message *Message) error
+}
+
+// Options configures the behavior of a Mattermost instance
+type Options struct {
+	notifier.HTTPOptions
+
+	// Channel, Username, IconURL and IconEmoji override the webhook's
+	// defaults, when the webhook allows it
+	Channel   string
+	Username  string
+	IconURL   string
+	IconEmoji string
+}
+
+// Message is a mattermost webhook message. Props are kept with the post,
+// and Props["card"] is shown in the post's info panel
+type Message struct {
+	Text        string                 `json:"text,omitempty"`
+	Channel     string                 `json:"channel,omitempty"`
+	Username    string                 `json:"username,omitempty"`
+	IconURL     string                 `json:"icon_url,omitempty"`
+	IconEmoji   string                 `json:"icon_emoji,omitempty"`
+	Attachments []*Attachment          `json:"attachments,omitempty"`
+	Props       map[string]interface{} `json:"props,omitempty"`
+}
+
+// Attachment is a message attachment, shown as a card below the text
+type Attachment struct {
+	Fallback  string             `json:"fallback,omitempty"`
+	Color     string             `json:"color,omitempty"`
+	Pretext   string             `json:"pretext,omitempty"`
+	Title     string             `json:"title,omitempty"`
+	TitleLink string             `json:"title_link,omitempty"`
+	Text      string             `json:"text,omitempty"`
+	Fields    []*AttachmentField `json:"fields,omitempty"`
+	ImageURL  string             `json:"image_url,omitempty"`
+	ThumbURL  string             `json:"thumb_url,omitempty"`
+	Footer    string             `json:"footer,omitempty"`
+}
+
+// AttachmentField is a title and value shown in an attachment. Short
+// fields are laid out side by side
+type AttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short,omitempty"`
+}
+
+type webhookMattermost struct {
+	webhookURI string
+	options    Options
+}
+
+// New constructs a new mattermost instance using an incoming webhook
+func New(webhookURI string, options Options) Mattermost {
+	options.HTTPOptions = options.HTTPOptions.WithDefaults()
+	return &webhookMattermost{webhookURI, options}
+}
+
+func (mattermost *webhookMattermost) Notify(notification *notifier.Notification) error {
+	return mattermost.PostMessage(NewNotificationMessage(notification))
+}
+
+func (mattermost *webhookMattermost) PostMessage(message *Message) error {
+	withOverrides := *message
+	if withOverrides.Channel == "" {
+		withOverrides.Channel = mattermost.options.Channel
+	}
+	if withOverrides.Username == "" {
+		withOverrides.Username = mattermost.options.Username
+	}
+	if withOverrides.IconURL == "" {
+		withOverrides.IconURL = mattermost.options.IconURL
+	}
+	if withOverrides.IconEmoji == "" {
+		withOverrides.IconEmoji = mattermost.options.IconEmoji
+	}
+
+	messageBytes, err := json.Marshal(&withOverrides)
+	if err != nil {
+		return err
+	}
+
+	_, err = mattermost.options.Post(mattermost.webhookURI, "application/json", messageBytes)
+	return err
+}
+
+// NewNotificationMessage constructs a message with an attachment showing
+// the notification's title, image and facts. The facts are also listed
+// on the card in the post's info panel
+func NewNotificationMessage(notification *notifier.Notification) *Message {
+	text := notification.Text
+	if text == "" {
+		text = fmt.Sprintf("[%v](%v)", notification.Title, notification.URL)
+	}
+	if len(notification.Mentions) > 0 {
+		text = strings.Join(notification.Mentions, " ") + " " + text
+	}
+
+	attachment := &Attachment{
+		Fallback:  text,
+		Title:     notification.Title,
+		TitleLink: notification.URL,
+		ImageURL:  notification.ImageURL,
+	}
+	for _, fact := range notification.Facts {
+		attachment.Fields = append(attachment.Fields, &AttachmentField{Title: fact.Name, Value: fact.Value, Short: true})
+	}
+
+	message := &Message{Text: text, Attachments: []*Attachment{attachment}}
+	if len(notification.Facts) > 0 {
+		message.Props = map[string]interface{}{"card": newCard(notification)}
+	}
+	return message
+}
+
+// newCard lists the notification's facts as markdown, one per line
+func newCard(notification *notifier.Notification) string {
+	lines := []string{fmt.Sprintf("#### [%v](%v)", notification.Title, notification.URL)}
+	for _, fact := range notification.Facts {
+		lines = append(lines, fmt.Sprintf("**%v:** %v", fact.Name, fact.Value))
+	}
+	return strings.Join(lines, "\n")
+}
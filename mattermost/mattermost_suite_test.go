@@ -0,0 +1,13 @@
+package mattermost_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestMattermost(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Mattermost Suite")
+}
@@ -0,0 +1,115 @@
+package mattermost_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/mattermost"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mattermost", func() {
+	var sut mattermost.Mattermost
+	var server *httptest.Server
+	var statusCode int
+	var requestBodies []string
+	var err error
+
+	BeforeEach(func() {
+		statusCode = 200
+		requestBodies = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requestBodies = append(requestBodies, string(body))
+			w.WriteHeader(statusCode)
+			w.Write([]byte("ok"))
+		}))
+
+		options := mattermost.Options{Channel: "town-square", Username: "uploader", IconURL: "https://example.com/icon.png"}
+		sut = mattermost.New(server.URL, options)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("sut.Notify(notification)", func() {
+		BeforeEach(func() {
+			err = sut.Notify(&notifier.Notification{
+				Title:    "example.png",
+				URL:      "https://dropbox.biz/example.png",
+				ImageURL: "https://dropbox.biz/example.png?raw=1",
+				Facts:    []notifier.Fact{{Name: "Job", Value: "ios-smoke"}},
+			})
+		})
+
+		It("Should have posted the text and an attachment with the overrides", func() {
+			Expect(requestBodies).To(HaveLen(1))
+			Expect(requestBodies[0]).To(MatchJSON(`{
+				"text": "[example.png](https://dropbox.biz/example.png)",
+				"channel": "town-square",
+				"username": "uploader",
+				"icon_url": "https://example.com/icon.png",
+				"attachments": [{
+					"fallback": "[example.png](https://dropbox.biz/example.png)",
+					"title": "example.png",
+					"title_link": "https://dropbox.biz/example.png",
+					"image_url": "https://dropbox.biz/example.png?raw=1",
+					"fields": [{"title": "Job", "value": "ios-smoke", "short": true}]
+				}],
+				"props": {"card": "#### [example.png](https://dropbox.biz/example.png)\n**Job:** ios-smoke"}
+			}`))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when the notification has no facts", func() {
+		BeforeEach(func() {
+			err = sut.Notify(&notifier.Notification{Title: "example.png", URL: "https://dropbox.biz/example.png"})
+		})
+
+		It("Should not have a card", func() {
+			Expect(err).To(BeNil())
+			Expect(requestBodies[0]).NotTo(ContainSubstring(`"props"`))
+		})
+	})
+
+	Describe("sut.PostMessage(message)", func() {
+		BeforeEach(func() {
+			err = sut.PostMessage(&mattermost.Message{
+				Text:    "Build failed",
+				Channel: "builds",
+				Props:   map[string]interface{}{"card": "Full build log"},
+			})
+		})
+
+		It("Should have kept the message's channel and props", func() {
+			Expect(requestBodies[0]).To(MatchJSON(`{
+				"text": "Build failed",
+				"channel": "builds",
+				"username": "uploader",
+				"icon_url": "https://example.com/icon.png",
+				"props": {"card": "Full build log"}
+			}`))
+		})
+	})
+
+	Describe("when mattermost rejects the message", func() {
+		BeforeEach(func() {
+			statusCode = 400
+			err = sut.PostMessage(&mattermost.Message{})
+		})
+
+		It("Should have a StatusError", func() {
+			Expect(err).To(BeAssignableToTypeOf(&notifier.StatusError{}))
+		})
+	})
+})
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+)
+
+// getMessageTemplate returns the template from --message-template or
+// --message-template-file, or nil when neither was given
+func getMessageTemplate(context *cli.Context) (*slack.Template, error) {
+	text := context.String("message-template")
+	templateFile := context.String("message-template-file")
+
+	if text != "" && templateFile != "" {
+		return nil, fmt.Errorf("Use either --message-template or --message-template-file, not both")
+	}
+
+	if templateFile != "" {
+		data, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+
+	if text == "" {
+		return nil, nil
+	}
+	return slack.ParseTemplate(text)
+}
+
+// getFields parses the repeated --field key=value flags
+func getFields(context *cli.Context) (map[string]string, error) {
+	fields := map[string]string{}
+	for _, field := range context.StringSlice("field") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid --field: %v, expected key=value", field)
+		}
+		fields[parts[0]] = parts[1]
+	}
+	return fields, nil
+}
+
+func newTemplateData(result *uploader.Result, publicURL string, fields map[string]string) *slack.TemplateData {
+	hostname, _ := os.Hostname()
+	return &slack.TemplateData{
+		PublicURL:      publicURL,
+		DirectURL:      result.DirectURL,
+		Path:           result.Path,
+		Size:           result.Size,
+		MIMEType:       result.MIMEType,
+		Width:          result.Width,
+		Height:         result.Height,
+		Rev:            result.Rev,
+		ServerModified: result.ServerModified,
+		ContentHash:    result.ContentHash,
+		Visibility:     result.Visibility,
+		Expires:        result.Expires,
+		Unchanged:      result.Unchanged,
+		Timestamp:      time.Now(),
+		Hostname:       hostname,
+		Fields:         fields,
+	}
+}
+
+// buildMessage renders the message template, or the default message
+// when there is none
+func buildMessage(messageTemplate *slack.Template, data *slack.TemplateData, requestedPath string) (*slack.Message, error) {
+	if messageTemplate != nil {
+		return messageTemplate.Render(data)
+	}
+
+	text := fmt.Sprintf("<%v|Click Here> To see the latest image upload", data.PublicURL)
+	if !strings.EqualFold(data.Path, requestedPath) {
+		text = fmt.Sprintf("%v (saved as %v)", text, slack.EscapeText(data.Path))
+	}
+	return &slack.Message{Text: text}, nil
+}
@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Func adapts a function to the Notifier interface
+type Func func(notification *Notification) error
+
+// Notify calls the function
+func (fn Func) Notify(notification *Notification) error {
+	return fn(notification)
+}
+
+// Delivery is a notification to send to one destination
+type Delivery struct {
+	// Name identifies the destination in the outcome. It should not
+	// contain secrets such as webhook tokens
+	Name string
+
+	Notifier     Notifier
+	Notification *Notification
+}
+
+// Outcome is how a Delivery went
+type Outcome struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// TimeoutError is the Outcome's Err when a destination took longer than
+// the timeout
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (err *TimeoutError) Error() string {
+	return fmt.Sprintf("Gave up after %v", err.Timeout)
+}
+
+// Deliver sends every delivery at the same time, and returns their
+// outcomes in the same order. A delivery taking longer than the timeout
+// is reported as a TimeoutError and left to finish on its own. Zero
+// means no timeout
+func Deliver(deliveries []*Delivery, timeout time.Duration) []*Outcome {
+	outcomes := make([]*Outcome, len(deliveries))
+
+	var wait sync.WaitGroup
+	for i, delivery := range deliveries {
+		wait.Add(1)
+		go func(i int, delivery *Delivery) {
+			defer wait.Done()
+			outcomes[i] = deliver(delivery, timeout)
+		}(i, delivery)
+	}
+	wait.Wait()
+
+	return outcomes
+}
+
+func deliver(delivery *Delivery, timeout time.Duration) *Outcome {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- delivery.Notifier.Notify(delivery.Notification)
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err := <-done:
+		return &Outcome{Name: delivery.Name, Err: err, Duration: time.Since(start)}
+	case <-expired:
+		return &Outcome{Name: delivery.Name, Err: &TimeoutError{timeout}, Duration: time.Since(start)}
+	}
+}
+
+// Failed returns the outcomes that have an error
+func Failed(outcomes []*Outcome) []*Outcome {
+	var failed []*Outcome
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			failed = append(failed, outcome)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,107 @@
+package notifier_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deliver", func() {
+	var notification *notifier.Notification
+	var running int32
+	var maxRunning int32
+
+	// slow is a notifier that takes the duration and fails with err
+	slow := func(duration time.Duration, err error) notifier.Notifier {
+		return notifier.Func(func(*notifier.Notification) error {
+			now := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if now <= max || atomic.CompareAndSwapInt32(&maxRunning, max, now) {
+					break
+				}
+			}
+			time.Sleep(duration)
+			atomic.AddInt32(&running, -1)
+			return err
+		})
+	}
+
+	BeforeEach(func() {
+		notification = &notifier.Notification{Title: "example.png"}
+		running = 0
+		maxRunning = 0
+	})
+
+	Describe("when every destination answers in time", func() {
+		var outcomes []*notifier.Outcome
+
+		BeforeEach(func() {
+			outcomes = notifier.Deliver([]*notifier.Delivery{
+				{Name: "first", Notifier: slow(20*time.Millisecond, nil), Notification: notification},
+				{Name: "second", Notifier: slow(20*time.Millisecond, errors.New("Rejected")), Notification: notification},
+				{Name: "third", Notifier: slow(20*time.Millisecond, nil), Notification: notification},
+			}, time.Second)
+		})
+
+		It("Should have delivered at the same time", func() {
+			Expect(maxRunning).To(Equal(int32(3)))
+		})
+
+		It("Should return the outcomes in order", func() {
+			Expect(outcomes).To(HaveLen(3))
+			Expect(outcomes[0].Name).To(Equal("first"))
+			Expect(outcomes[0].Err).To(BeNil())
+			Expect(outcomes[1].Name).To(Equal("second"))
+			Expect(outcomes[1].Err).To(MatchError("Rejected"))
+			Expect(outcomes[2].Err).To(BeNil())
+		})
+
+		It("Should list the failed outcomes", func() {
+			failed := notifier.Failed(outcomes)
+			Expect(failed).To(HaveLen(1))
+			Expect(failed[0].Name).To(Equal("second"))
+		})
+	})
+
+	Describe("when a destination takes longer than the timeout", func() {
+		var outcomes []*notifier.Outcome
+		var start time.Time
+		var release chan struct{}
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			// the abandoned goroutine keeps its own channel, as release is
+			// reassigned for the next spec while it may still be waiting
+			released := release
+			stuck := notifier.Func(func(*notifier.Notification) error {
+				<-released
+				return nil
+			})
+
+			start = time.Now()
+			outcomes = notifier.Deliver([]*notifier.Delivery{
+				{Name: "fast", Notifier: slow(0, nil), Notification: notification},
+				{Name: "stuck", Notifier: stuck, Notification: notification},
+			}, 50*time.Millisecond)
+		})
+
+		AfterEach(func() {
+			close(release)
+		})
+
+		It("Should not have waited for it", func() {
+			Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+		})
+
+		It("Should report a TimeoutError for it", func() {
+			Expect(outcomes[0].Err).To(BeNil())
+			Expect(outcomes[1].Err).To(Equal(&notifier.TimeoutError{Timeout: 50 * time.Millisecond}))
+		})
+	})
+})
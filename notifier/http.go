@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
+)
+
+// DefaultTimeout limits each request a notifier makes when no HTTPClient
+// is given
+const DefaultTimeout = 30 * time.Second
+
+// HTTPOptions configures how a notifier that posts over HTTP makes its
+// requests
+type HTTPOptions struct {
+	// Retry is applied to every request. Rate limits, server errors and
+	// network errors are retried, waiting as long as the server asks
+	Retry retry.Policy
+
+	// HTTPClient makes the requests. It defaults to a client that gives
+	// up after DefaultTimeout
+	HTTPClient *http.Client
+}
+
+// WithDefaults fills in the options that were left empty
+func (options HTTPOptions) WithDefaults() HTTPOptions {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return options
+}
+
+// Post sends the body to the url, retrying according to the options, and
+// returns the response body
+func (options HTTPOptions) Post(url, contentType string, body []byte) ([]byte, error) {
+	return options.Send(func() (*http.Request, error) {
+		request, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", contentType)
+		return request, nil
+	})
+}
+
+// Send makes the request built by newRequest, building it again for
+// each retry, and returns the response body
+func (options HTTPOptions) Send(newRequest func() (*http.Request, error)) ([]byte, error) {
+	var body []byte
+	err := options.Retry.Do(func() error {
+		request, err := newRequest()
+		if err != nil {
+			return err
+		}
+
+		body, err = Do(options.HTTPClient, request)
+		return err
+	})
+	return body, err
+}
+
+// StatusError is returned when a destination responds with a status
+// outside of 2xx
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+
+	// Destination names where the request was sent, when it is known
+	Destination string
+}
+
+func (err *StatusError) Error() string {
+	if err.Destination != "" {
+		return fmt.Sprintf("Non 2xx status received from %v: %v, %v", err.Destination, err.StatusCode, err.Body)
+	}
+	return fmt.Sprintf("Non 2xx status received: %v, %v", err.StatusCode, err.Body)
+}
+
+// Retryable is true for rate limits and server errors
+func (err *StatusError) Retryable() bool {
+	return err.StatusCode == http.StatusTooManyRequests || err.StatusCode >= 500
+}
+
+// RetryAfterDuration is how long the destination asked to wait before
+// retrying
+func (err *StatusError) RetryAfterDuration() time.Duration {
+	return err.RetryAfter
+}
+
+// Do sends the request with the client and returns the response body,
+// or a StatusError when the status is outside of 2xx
+func Do(client *http.Client, request *http.Request) ([]byte, error) {
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, NewStatusError(resp, body)
+	}
+
+	return body, nil
+}
+
+// NewStatusError describes the response, with the Retry-After it asked
+// for
+func NewStatusError(resp *http.Response, body []byte) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After")),
+		Body:       string(body),
+	}
+}
+
+// ParseRetryAfter reads a Retry-After header given in seconds, which may
+// be fractional, returning zero when it is missing or malformed
+func ParseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
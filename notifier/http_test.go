@@ -0,0 +1,135 @@
+package notifier_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Do", func() {
+	var server *httptest.Server
+	var statusCode int
+	var body []byte
+	var err error
+
+	BeforeEach(func() {
+		statusCode = http.StatusAccepted
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if statusCode == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "7")
+			}
+			w.WriteHeader(statusCode)
+			w.Write([]byte("response"))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		request, _ := http.NewRequest("POST", server.URL, nil)
+		body, err = notifier.Do(http.DefaultClient, request)
+	})
+
+	Describe("when the destination accepts the request", func() {
+		It("Should return the body", func() {
+			Expect(string(body)).To(Equal("response"))
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when the destination rate limits the request", func() {
+		BeforeEach(func() {
+			statusCode = http.StatusTooManyRequests
+		})
+
+		It("Should have a retryable StatusError with the Retry-After", func() {
+			Expect(err).To(Equal(&notifier.StatusError{StatusCode: 429, RetryAfter: 7 * time.Second, Body: "response"}))
+			Expect(retry.IsRetryable(err)).To(BeTrue())
+		})
+	})
+
+	Describe("when the destination rejects the request", func() {
+		BeforeEach(func() {
+			statusCode = http.StatusBadRequest
+		})
+
+		It("Should have a StatusError that is not retryable", func() {
+			Expect(err.Error()).To(Equal("Non 2xx status received: 400, response"))
+			Expect(retry.IsRetryable(err)).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("HTTPOptions", func() {
+	var server *httptest.Server
+	var statusCodes []int
+	var requests []*http.Request
+	var sleeps []time.Duration
+	var options notifier.HTTPOptions
+
+	BeforeEach(func() {
+		statusCodes, requests, sleeps = nil, nil, nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			statusCode := 200
+			if len(requests) <= len(statusCodes) {
+				statusCode = statusCodes[len(requests)-1]
+			}
+			w.Header().Set("Retry-After", "1.5")
+			w.WriteHeader(statusCode)
+			w.Write([]byte("response"))
+		}))
+
+		policy := retry.Policy{MaxAttempts: 3, Sleep: func(duration time.Duration) {
+			sleeps = append(sleeps, duration)
+		}}
+		options = notifier.HTTPOptions{Retry: policy}.WithDefaults()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("Should default to a client with a timeout", func() {
+		Expect(notifier.HTTPOptions{}.WithDefaults().HTTPClient.Timeout).To(Equal(notifier.DefaultTimeout))
+	})
+
+	Describe("options.Post(url, contentType, body)", func() {
+		It("Should post the body with the content type", func() {
+			body, err := options.Post(server.URL, "application/json", []byte("{}"))
+			Expect(err).To(BeNil())
+			Expect(string(body)).To(Equal("response"))
+			Expect(requests[0].Method).To(Equal("POST"))
+			Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/json"))
+		})
+
+		It("Should retry rate limits after the Retry-After", func() {
+			statusCodes = []int{429}
+			_, err := options.Post(server.URL, "application/json", []byte("{}"))
+			Expect(err).To(BeNil())
+			Expect(requests).To(HaveLen(2))
+			Expect(sleeps).To(Equal([]time.Duration{1500 * time.Millisecond}))
+		})
+	})
+})
+
+var _ = Describe("ParseRetryAfter", func() {
+	It("Should read whole and fractional seconds", func() {
+		Expect(notifier.ParseRetryAfter("3")).To(Equal(3 * time.Second))
+		Expect(notifier.ParseRetryAfter("0.25")).To(Equal(250 * time.Millisecond))
+	})
+
+	It("Should be zero when missing or malformed", func() {
+		Expect(notifier.ParseRetryAfter("")).To(BeZero())
+		Expect(notifier.ParseRetryAfter("-1")).To(BeZero())
+		Expect(notifier.ParseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT")).To(BeZero())
+	})
+})
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"time"
+)
+
+// Notifier announces an upload somewhere people will see it
+type Notifier interface {
+	Notify(notification *Notification) error
+}
+
+// Notification describes an upload. Each Notifier shows as much of it as
+// its destination supports
+type Notification struct {
+	// Title is a short name for the upload, such as its filename
+	Title string
+
+	// Text is the message, which may contain the destination's markup
+	Text string
+
+	// URL links to the upload
+	URL string
+
+	// ImageURL serves the image itself, for showing it inline
+	ImageURL string
+
+	// Image is the uploaded content, for destinations that attach it
+	// instead of linking to it
+	Image []byte
+
+	// Filename is the name of the uploaded file
+	Filename string
+
+	// Facts are name and value pairs shown along with the upload
+	Facts []Fact
+
+	// Mentions are people or groups notified along with the text, in
+	// the destination's own syntax. Destinations without mentions, such
+	// as email, leave them out
+	Mentions []string
+
+	// Timestamp is when the upload happened
+	Timestamp time.Time
+}
+
+// Fact is a name and value pair shown along with an upload
+type Fact struct {
+	Name  string
+	Value string
+}
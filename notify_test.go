@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newContext parses the arguments with the app's flags
+func newContext(arguments ...string) *cli.Context {
+	var context *cli.Context
+	app := newApp()
+	app.Action = func(appContext *cli.Context) {
+		context = appContext
+	}
+	Expect(app.Run(append([]string{app.Name}, arguments...))).To(Succeed())
+	return context
+}
+
+var _ = Describe("destination.delivery", func() {
+	var messageTemplate *slack.Template
+	var data *slack.TemplateData
+
+	BeforeEach(func() {
+		var err error
+		messageTemplate, err = slack.ParseTemplate("Saved {{.Path}} for {{.Fields.job}}")
+		Expect(err).To(BeNil())
+
+		data = &slack.TemplateData{
+			Path:   "/builds/<ios>/a&b.png",
+			Fields: map[string]string{"job": "ios & web"},
+		}
+	})
+
+	texts := map[string]string{
+		"slack://hooks.slack.com/services/T0/B0/X": "Saved /builds/&lt;ios&gt;/a&amp;b.png for ios &amp; web",
+		"teams://example.webhook.office.com/hook":  "Saved /builds/<ios>/a&b.png for ios & web",
+		"discord://discord.com/api/webhooks/1/x":   "Saved /builds/<ios>/a&b.png for ios & web",
+		"mattermost://chat.example.com/hooks/x":    "Saved /builds/<ios>/a&b.png for ios & web",
+		"webhook://ci.example.com/uploads":         "Saved /builds/<ios>/a&b.png for ios & web",
+		"smtp://mail.example.com:587":              "Saved /builds/<ios>/a&b.png for ios & web",
+	}
+	for rawDestination, text := range texts {
+		rawDestination, text := rawDestination, text
+
+		It("Should render the text for "+rawDestination, func() {
+			context := newContext("--email-from", "uploader@example.com", "--email-to", "lead@example.com")
+			destination, err := newDestination(context, "notify[1]", rawDestination, messageTemplate)
+			Expect(err).To(BeNil())
+
+			delivery, err := destination.delivery(newNotification(data, nil), data)
+			Expect(err).To(BeNil())
+			Expect(delivery.Notification.Text).To(Equal(text))
+		})
+	}
+})
+
+var _ = Describe("getNotifyHTTPOptions", func() {
+	It("Should limit each request by --notify-request-timeout", func() {
+		context := newContext("--notify-timeout", "1m", "--notify-request-timeout", "5s")
+		Expect(getNotifyHTTPOptions(context).HTTPClient.Timeout).To(Equal(5 * time.Second))
+	})
+
+	It("Should leave time to retry within --notify-timeout by default", func() {
+		context := newContext()
+		Expect(getNotifyHTTPOptions(context).HTTPClient.Timeout).To(BeNumerically("<", context.Duration("notify-timeout")))
+	})
+})
+
+var _ = Describe("failsRun", func() {
+	var outcomes []*notifier.Outcome
+	rejected := &slack.ChannelNotFoundError{}
+
+	BeforeEach(func() {
+		outcomes = []*notifier.Outcome{{Name: "slack webhook"}, {Name: "notify[1] teams://example.com"}}
+	})
+
+	Describe("when every destination was notified", func() {
+		It("Should not fail the run with either policy", func() {
+			Expect(failsRun(outcomes, failOnAny)).To(BeFalse())
+			Expect(failsRun(outcomes, failOnAll)).To(BeFalse())
+		})
+	})
+
+	Describe("when one destination failed", func() {
+		BeforeEach(func() {
+			outcomes[0].Err = rejected
+		})
+
+		It("Should fail the run with any, keeping the error", func() {
+			fails, err := failsRun(outcomes, failOnAny)
+			Expect(fails).To(BeTrue())
+			Expect(err).To(MatchError(HavePrefix("1 of 2 notifications failed")))
+			Expect(errors.Is(err, rejected)).To(BeTrue())
+		})
+
+		It("Should not fail the run with all", func() {
+			Expect(failsRun(outcomes, failOnAll)).To(BeFalse())
+		})
+	})
+
+	Describe("when every destination failed", func() {
+		BeforeEach(func() {
+			outcomes[0].Err = rejected
+			outcomes[1].Err = &notifier.TimeoutError{Timeout: time.Second}
+		})
+
+		It("Should fail the run with either policy", func() {
+			for _, failOn := range []string{failOnAny, failOnAll} {
+				fails, err := failsRun(outcomes, failOn)
+				Expect(fails).To(BeTrue())
+				Expect(err).To(MatchError("2 of 2 notifications failed"))
+			}
+		})
+	})
+})
@@ -0,0 +1,198 @@
+package pathtemplate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+)
+
+// Data holds the values available to a path template
+type Data struct {
+	// Date is the UTC date, as in 2016-01-02
+	Date string
+
+	// Time is the UTC time of day, as in 150405
+	Time string
+
+	// Timestamp is the number of seconds since the unix epoch
+	Timestamp int64
+
+	// Hash is the hex encoded SHA-256 of the content. It is only filled
+	// in by SetHash when NeedsHash
+	Hash string
+
+	// ShortHash is the first 8 characters of Hash
+	ShortHash string
+
+	// Ext is the extension of the detected image type, without a dot, or
+	// empty when the content is not a recognized image. It is only filled
+	// in when NeedsExt
+	Ext string
+
+	// Hostname is the name of the machine doing the upload
+	Hostname string
+
+	// Env holds every environment variable
+	Env map[string]string
+
+	// Job, Build, Branch and Commit are read from the environment
+	// variables of common CI services
+	Job    string
+	Build  string
+	Branch string
+	Commit string
+}
+
+var ciVariables = map[string][]string{
+	"Job":    {"JOB_NAME", "CI_JOB_NAME", "GITHUB_JOB", "CIRCLE_JOB", "TRAVIS_JOB_NAME"},
+	"Build":  {"BUILD_NUMBER", "CI_PIPELINE_ID", "GITHUB_RUN_NUMBER", "CIRCLE_BUILD_NUM", "TRAVIS_BUILD_NUMBER"},
+	"Branch": {"GIT_BRANCH", "CI_COMMIT_REF_NAME", "GITHUB_REF_NAME", "CIRCLE_BRANCH", "TRAVIS_BRANCH"},
+	"Commit": {"GIT_COMMIT", "CI_COMMIT_SHA", "GITHUB_SHA", "CIRCLE_SHA1", "TRAVIS_COMMIT"},
+}
+
+// NewData collects the template values for an upload at now, with the
+// given environment in the "key=value" form of os.Environ. The values
+// that depend on the content are left for the caller to fill in
+func NewData(now time.Time, environ []string) *Data {
+	hostname, _ := os.Hostname()
+
+	env := make(map[string]string, len(environ))
+	for _, keyValue := range environ {
+		parts := strings.SplitN(keyValue, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	now = now.UTC()
+	return &Data{
+		Date:      now.Format("2006-01-02"),
+		Time:      now.Format("150405"),
+		Timestamp: now.Unix(),
+		Hostname:  hostname,
+		Env:       env,
+		Job:       firstEnv(env, ciVariables["Job"]),
+		Build:     firstEnv(env, ciVariables["Build"]),
+		Branch:    firstEnv(env, ciVariables["Branch"]),
+		Commit:    firstEnv(env, ciVariables["Commit"]),
+	}
+}
+
+// SetHash fills in Hash and ShortHash from the SHA-256 sum of the content
+func (data *Data) SetHash(sum []byte) {
+	data.Hash = hex.EncodeToString(sum)
+	data.ShortHash = data.Hash[:8]
+}
+
+// NeedsHash reports whether the path template refers to Hash or
+// ShortHash, which take reading the whole content before the upload
+func NeedsHash(path string) bool {
+	return usesField(path, "Hash") || usesField(path, "ShortHash")
+}
+
+// NeedsExt reports whether the path template refers to Ext, which takes
+// the start of the content
+func NeedsExt(path string) bool {
+	return usesField(path, "Ext")
+}
+
+// DetectExt returns the extension the uploader gives the image type of
+// head, the start of the content, or "" when it is not a recognized
+// image
+func DetectExt(head []byte) string {
+	return uploader.SniffContentType(head).Ext
+}
+
+// Render evaluates the path template with data. Paths without any
+// template actions are returned unchanged
+func Render(path string, data *Data) (string, error) {
+	if !strings.Contains(path, "{{") {
+		return path, nil
+	}
+
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered bytes.Buffer
+	err = tmpl.Execute(&rendered, data)
+	if err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// usesField reports whether the template refers to the field anywhere.
+// A template that does not parse uses nothing, and fails in Render
+func usesField(path string, field string) bool {
+	if !strings.Contains(path, "{{") {
+		return false
+	}
+
+	tmpl, err := template.New("path").Parse(path)
+	if err != nil {
+		return false
+	}
+	return nodeUsesField(tmpl.Tree.Root, field)
+}
+
+func nodeUsesField(node parse.Node, field string) bool {
+	switch node := node.(type) {
+	case *parse.FieldNode:
+		return node.Ident[0] == field
+	case *parse.VariableNode:
+		return len(node.Ident) > 1 && node.Ident[0] == "$" && node.Ident[1] == field
+	case *parse.ChainNode:
+		return nodeUsesField(node.Node, field)
+	case *parse.ListNode:
+		if node == nil {
+			return false
+		}
+		for _, child := range node.Nodes {
+			if nodeUsesField(child, field) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeUsesField(node.Pipe, field)
+	case *parse.TemplateNode:
+		return node.Pipe != nil && nodeUsesField(node.Pipe, field)
+	case *parse.PipeNode:
+		for _, command := range node.Cmds {
+			if nodeUsesField(command, field) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			if nodeUsesField(arg, field) {
+				return true
+			}
+		}
+	case *parse.IfNode:
+		return nodeUsesField(&node.BranchNode, field)
+	case *parse.RangeNode:
+		return nodeUsesField(&node.BranchNode, field)
+	case *parse.WithNode:
+		return nodeUsesField(&node.BranchNode, field)
+	case *parse.BranchNode:
+		return nodeUsesField(node.Pipe, field) || nodeUsesField(node.List, field) || nodeUsesField(node.ElseList, field)
+	}
+	return false
+}
+
+func firstEnv(env map[string]string, names []string) string {
+	for _, name := range names {
+		if value := env[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,13 @@
+package pathtemplate_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPathtemplate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pathtemplate Suite")
+}
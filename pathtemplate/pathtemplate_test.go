@@ -0,0 +1,162 @@
+package pathtemplate_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/pathtemplate"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const IMAGE_DATA = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAAAAAA6fptVAAAACklEQVR4nGP6DwABBQECz6AuzQAAAABJRU5ErkJggg=="
+
+var _ = Describe("Pathtemplate", func() {
+	var data *pathtemplate.Data
+	var path string
+	var err error
+
+	BeforeEach(func() {
+		content, err := base64.StdEncoding.DecodeString(IMAGE_DATA)
+		Expect(err).To(BeNil())
+
+		now := time.Date(2016, 1, 2, 15, 4, 5, 0, time.FixedZone("MST", -7*60*60))
+		environ := []string{"JOB_NAME=ios-smoke", "BUILD_NUMBER=42", "GREETING=a=b"}
+		data = pathtemplate.NewData(now, environ)
+		sum := sha256.Sum256(content)
+		data.SetHash(sum[:])
+		data.Ext = pathtemplate.DetectExt(content)
+	})
+
+	Describe("NewData", func() {
+		It("Should use the UTC date and time", func() {
+			Expect(data.Date).To(Equal("2016-01-02"))
+			Expect(data.Time).To(Equal("220405"))
+		})
+
+		It("Should leave the content values to the caller", func() {
+			Expect(pathtemplate.NewData(time.Now(), nil).Hash).To(Equal(""))
+		})
+
+		It("Should read the CI variables", func() {
+			Expect(data.Job).To(Equal("ios-smoke"))
+			Expect(data.Build).To(Equal("42"))
+			Expect(data.Branch).To(Equal(""))
+		})
+
+		It("Should keep everything after the first = in environment values", func() {
+			Expect(data.Env["GREETING"]).To(Equal("a=b"))
+		})
+	})
+
+	Describe("SetHash", func() {
+		It("Should hex encode the sum", func() {
+			Expect(data.Hash).To(HaveLen(64))
+			Expect(data.ShortHash).To(Equal(data.Hash[:8]))
+		})
+	})
+
+	Describe("DetectExt", func() {
+		It("Should detect the image extension", func() {
+			Expect(data.Ext).To(Equal("png"))
+		})
+
+		It("Should use the extension the uploader gives the image type", func() {
+			Expect(pathtemplate.DetectExt([]byte("\xff\xd8\xff\xe0"))).To(Equal("jpg"))
+		})
+
+		It("Should be empty when the content is not an image", func() {
+			Expect(pathtemplate.DetectExt([]byte("plain text"))).To(Equal(""))
+		})
+	})
+
+	Describe("NeedsHash", func() {
+		It("Should be true when the template uses Hash", func() {
+			Expect(pathtemplate.NeedsHash("/uploads/{{.Hash}}.png")).To(BeTrue())
+		})
+
+		It("Should be true when the template uses ShortHash in a pipeline", func() {
+			Expect(pathtemplate.NeedsHash(`/uploads/{{printf "%v-%v" .Job .ShortHash}}.png`)).To(BeTrue())
+		})
+
+		It("Should be true when the template uses Hash in a branch", func() {
+			Expect(pathtemplate.NeedsHash("/uploads/{{if .Job}}{{.Job}}{{else}}{{$.Hash}}{{end}}.png")).To(BeTrue())
+		})
+
+		It("Should be false when the template does not", func() {
+			Expect(pathtemplate.NeedsHash("/uploads/{{.Env.Hash}}-{{.Date}}.{{.Ext}}")).To(BeFalse())
+		})
+
+		It("Should be false for a literal path", func() {
+			Expect(pathtemplate.NeedsHash("/uploads/Hash.png")).To(BeFalse())
+		})
+	})
+
+	Describe("NeedsExt", func() {
+		It("Should be true when the template uses Ext", func() {
+			Expect(pathtemplate.NeedsExt("/uploads/{{.Date}}.{{.Ext}}")).To(BeTrue())
+		})
+
+		It("Should be false when the template does not", func() {
+			Expect(pathtemplate.NeedsExt("/uploads/{{.Date}}.png")).To(BeFalse())
+		})
+	})
+
+	Describe("Render", func() {
+		Describe("with placeholders", func() {
+			BeforeEach(func() {
+				path, err = pathtemplate.Render("/failures/{{.Date}}/{{.Job}}-{{.Time}}-{{.ShortHash}}.{{.Ext}}", data)
+			})
+
+			It("Should fill them in", func() {
+				Expect(path).To(Equal("/failures/2016-01-02/ios-smoke-220405-" + data.ShortHash + ".png"))
+			})
+
+			It("Should not have an error", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("with an environment variable", func() {
+			BeforeEach(func() {
+				path, err = pathtemplate.Render("/builds/{{.Env.BUILD_NUMBER}}.png", data)
+			})
+
+			It("Should fill it in", func() {
+				Expect(path).To(Equal("/builds/42.png"))
+			})
+		})
+
+		Describe("with a literal path", func() {
+			BeforeEach(func() {
+				path, err = pathtemplate.Render("/failures/example-2016-01-02.png", data)
+			})
+
+			It("Should return it unchanged", func() {
+				Expect(path).To(Equal("/failures/example-2016-01-02.png"))
+			})
+		})
+
+		Describe("with an unknown placeholder", func() {
+			BeforeEach(func() {
+				path, err = pathtemplate.Render("/failures/{{.Nope}}.png", data)
+			})
+
+			It("Should have an error", func() {
+				Expect(err).NotTo(BeNil())
+			})
+		})
+
+		Describe("with a missing environment variable", func() {
+			BeforeEach(func() {
+				path, err = pathtemplate.Render("/failures/{{.Env.MISSING}}.png", data)
+			})
+
+			It("Should have an error", func() {
+				Expect(err).NotTo(BeNil())
+			})
+		})
+	})
+})
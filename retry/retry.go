@@ -0,0 +1,106 @@
+package retry
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// Policy describes how a failing call is retried. The zero value makes a
+// single attempt and never retries
+type Policy struct {
+	// MaxAttempts is the most times the call is made, including the first
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first retry. It doubles with each
+	// further retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the wait between attempts, including waits asked for
+	// by the server. Zero means no cap
+	MaxDelay time.Duration
+
+	// Retryable decides which errors are worth another attempt. It
+	// defaults to IsRetryable
+	Retryable func(err error) bool
+
+	// Sleep waits between attempts. It defaults to time.Sleep
+	Sleep func(duration time.Duration)
+}
+
+// Retryable is implemented by errors that know whether the call that
+// produced them is worth retrying
+type Retryable interface {
+	Retryable() bool
+}
+
+// RetryAfter is implemented by errors that carry the server's
+// Retry-After, which takes the place of the backoff delay. Errors whose
+// client did not pass on the header, such as those of the dropbox
+// client, return zero and are backed off like any other
+type RetryAfter interface {
+	RetryAfterDuration() time.Duration
+}
+
+// IsRetryable reports whether err asks to be retried, or is a network
+// error that did not get a response from the server
+func IsRetryable(err error) bool {
+	var retryable Retryable
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	var netError net.Error
+	return errors.As(err, &netError)
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable,
+// or has been called MaxAttempts times. It returns the last error
+func (policy Policy) Do(fn func() error) error {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	sleep := policy.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		sleep(policy.Delay(attempt, err))
+	}
+}
+
+// Delay returns how long to wait after the given attempt failed with err
+func (policy Policy) Delay(attempt int, err error) time.Duration {
+	var retryAfter RetryAfter
+	if errors.As(err, &retryAfter) && retryAfter.RetryAfterDuration() > 0 {
+		return policy.capped(retryAfter.RetryAfterDuration())
+	}
+
+	delay := policy.BaseDelay
+	for i := 1; i < attempt && (policy.MaxDelay == 0 || delay < policy.MaxDelay); i++ {
+		delay *= 2
+	}
+	delay = policy.capped(delay)
+	if delay <= 0 {
+		return 0
+	}
+
+	// jitter between half and all of the delay, so that clients that
+	// failed together do not retry together
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func (policy Policy) capped(delay time.Duration) time.Duration {
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		return policy.MaxDelay
+	}
+	return delay
+}
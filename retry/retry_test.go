@@ -0,0 +1,181 @@
+package retry_test
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeError struct {
+	retryable  bool
+	retryAfter time.Duration
+}
+
+func (err *fakeError) Error() string {
+	return "fake error"
+}
+
+func (err *fakeError) Retryable() bool {
+	return err.retryable
+}
+
+func (err *fakeError) RetryAfterDuration() time.Duration {
+	return err.retryAfter
+}
+
+var _ = Describe("Policy", func() {
+	var sut retry.Policy
+	var sleeps []time.Duration
+	var calls int
+	var err error
+
+	BeforeEach(func() {
+		sleeps = nil
+		calls = 0
+		sut = retry.Policy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Second,
+			MaxDelay:    10 * time.Second,
+			Sleep: func(duration time.Duration) {
+				sleeps = append(sleeps, duration)
+			},
+		}
+	})
+
+	Describe("when the call succeeds", func() {
+		BeforeEach(func() {
+			err = sut.Do(func() error {
+				calls++
+				return nil
+			})
+		})
+
+		It("Should have called it once", func() {
+			Expect(calls).To(Equal(1))
+			Expect(sleeps).To(BeEmpty())
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when the call keeps failing with a retryable error", func() {
+		BeforeEach(func() {
+			err = sut.Do(func() error {
+				calls++
+				return &fakeError{retryable: true}
+			})
+		})
+
+		It("Should have made MaxAttempts calls", func() {
+			Expect(calls).To(Equal(3))
+		})
+
+		It("Should have backed off exponentially with jitter", func() {
+			Expect(sleeps).To(HaveLen(2))
+			Expect(sleeps[0]).To(BeNumerically(">=", 500*time.Millisecond))
+			Expect(sleeps[0]).To(BeNumerically("<=", time.Second))
+			Expect(sleeps[1]).To(BeNumerically(">=", time.Second))
+			Expect(sleeps[1]).To(BeNumerically("<=", 2*time.Second))
+		})
+
+		It("Should return the last error", func() {
+			Expect(err).To(Equal(&fakeError{retryable: true}))
+		})
+	})
+
+	Describe("when the call fails and then succeeds", func() {
+		BeforeEach(func() {
+			err = sut.Do(func() error {
+				calls++
+				if calls == 1 {
+					return &fakeError{retryable: true}
+				}
+				return nil
+			})
+		})
+
+		It("Should have stopped after the success", func() {
+			Expect(calls).To(Equal(2))
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when the call fails with an error that is not retryable", func() {
+		BeforeEach(func() {
+			err = sut.Do(func() error {
+				calls++
+				return fmt.Errorf("Bad request")
+			})
+		})
+
+		It("Should not have retried", func() {
+			Expect(calls).To(Equal(1))
+			Expect(err.Error()).To(Equal("Bad request"))
+		})
+	})
+
+	Describe("when the error carries a Retry-After", func() {
+		BeforeEach(func() {
+			err = sut.Do(func() error {
+				calls++
+				return &fakeError{retryable: true, retryAfter: 7 * time.Second}
+			})
+		})
+
+		It("Should have waited for the Retry-After", func() {
+			Expect(sleeps).To(Equal([]time.Duration{7 * time.Second, 7 * time.Second}))
+		})
+	})
+
+	Describe("when the Retry-After is longer than MaxDelay", func() {
+		BeforeEach(func() {
+			err = sut.Do(func() error {
+				calls++
+				return &fakeError{retryable: true, retryAfter: time.Minute}
+			})
+		})
+
+		It("Should have waited for MaxDelay", func() {
+			Expect(sleeps[0]).To(Equal(10 * time.Second))
+		})
+	})
+
+	Describe("with the zero value", func() {
+		BeforeEach(func() {
+			err = retry.Policy{}.Do(func() error {
+				calls++
+				return &fakeError{retryable: true}
+			})
+		})
+
+		It("Should only have made one attempt", func() {
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Describe("IsRetryable", func() {
+		It("Should retry network errors", func() {
+			Expect(retry.IsRetryable(&net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")})).To(BeTrue())
+		})
+
+		It("Should ask errors that know", func() {
+			Expect(retry.IsRetryable(&fakeError{retryable: false})).To(BeFalse())
+			Expect(retry.IsRetryable(&fakeError{retryable: true})).To(BeTrue())
+		})
+
+		It("Should look through wrapped errors", func() {
+			Expect(retry.IsRetryable(fmt.Errorf("uploading: %w", &fakeError{retryable: true}))).To(BeTrue())
+		})
+
+		It("Should not retry anything else", func() {
+			Expect(retry.IsRetryable(fmt.Errorf("Bad request"))).To(BeFalse())
+		})
+	})
+})
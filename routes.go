@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/codegangsta/cli"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/routing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+)
+
+// routes are the --routing-config routes, with the destinations of each
+// constructed up front so mistakes show up before anything is uploaded
+type routes struct {
+	config       *routing.Config
+	destinations map[*routing.Route][]*destination
+}
+
+// getRoutes loads --routing-config, or returns nil when it was not given.
+// Routes without a template of their own use the message template
+func getRoutes(context *cli.Context, messageTemplate *slack.Template) (*routes, error) {
+	configPath := context.String("routing-config")
+	if configPath == "" {
+		return nil, nil
+	}
+
+	config, err := routing.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations := map[*routing.Route][]*destination{}
+	for _, route := range config.Routes {
+		destinations[route], err = getRouteDestinations(context, route, messageTemplate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &routes{config, destinations}, nil
+}
+
+// getRouteDestinations constructs the destinations and channels of the
+// route
+func getRouteDestinations(context *cli.Context, route *routing.Route, messageTemplate *slack.Template) ([]*destination, error) {
+	routeTemplate, err := getRouteTemplate(route)
+	if err != nil {
+		return nil, err
+	}
+	if routeTemplate == nil {
+		routeTemplate = messageTemplate
+	}
+
+	var destinations []*destination
+	for _, rawDestination := range route.Notify {
+		destination, err := newDestination(context, route.Name, rawDestination, routeTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("Routing %v: %v", route.Name, err)
+		}
+		destinations = append(destinations, destination)
+	}
+
+	slackBotToken := context.String("slack-bot-token")
+	if len(route.Channels) > 0 && slackBotToken == "" {
+		return nil, fmt.Errorf("Routing %v posts to channels, which requires --slack-bot-token", route.Name)
+	}
+	for _, channel := range route.Channels {
+		options := slack.Options{
+			HTTPOptions: notifier.HTTPOptions{
+				Retry:      getRetryPolicy(context),
+				HTTPClient: &http.Client{Timeout: context.Duration("slack-timeout")},
+			},
+			BaseURL:   context.String("slack-base-url"),
+			Username:  context.String("slack-username"),
+			IconURL:   context.String("slack-icon-url"),
+			IconEmoji: context.String("slack-icon-emoji"),
+		}
+		destinations = append(destinations, &destination{
+			name:     fmt.Sprintf("%v slack %v", route.Name, channel),
+			notifier: slack.NewWebAPI(slackBotToken, channel, options),
+			template: routeTemplate,
+			mrkdwn:   true,
+		})
+	}
+
+	for _, destination := range destinations {
+		destination.mentions = route.Mentions
+	}
+	return destinations, nil
+}
+
+// getRouteTemplate parses the route's template, or returns nil when it
+// has none
+func getRouteTemplate(route *routing.Route) (*slack.Template, error) {
+	text := route.Template
+	if route.TemplateFile != "" {
+		data, err := ioutil.ReadFile(route.TemplateFile)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+
+	if text == "" {
+		return nil, nil
+	}
+	return slack.ParseTemplate(text)
+}
+
+// attachesImage is true when a destination of any route sends the image
+// itself
+func (routes *routes) attachesImage() bool {
+	for _, destinations := range routes.destinations {
+		for _, destination := range destinations {
+			if destination.attachesImage {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deliveries returns a delivery to each destination of the routes
+// matching the final dropbox path
+func (routes *routes) deliveries(dropboxPath string, notification *notifier.Notification, data *slack.TemplateData) ([]*notifier.Delivery, error) {
+	var deliveries []*notifier.Delivery
+	for _, route := range routes.config.Match(dropboxPath) {
+		debug("routing %v matched %v", route.Name, dropboxPath)
+
+		for _, destination := range routes.destinations[route] {
+			delivery, err := destination.delivery(notification, data)
+			if err != nil {
+				return nil, err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("routes.deliveries", func() {
+	var dir string
+	var deliveries []*notifier.Delivery
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "routes")
+		Expect(err).To(BeNil())
+
+		configPath := filepath.Join(dir, "routes.yml")
+		err = ioutil.WriteFile(configPath, []byte(`
+routes:
+  - name: ios
+    glob: /builds/**
+    template: "iOS {{.Path}}"
+    notify: ["mattermost://chat.example.com/hooks/x"]
+    channels: ["#ios"]
+  - name: everything
+    glob: /**
+    notify: ["teams://example.webhook.office.com/hook"]
+`), 0644)
+		Expect(err).To(BeNil())
+
+		messageTemplate, err := slack.ParseTemplate("Saved {{.Path}}")
+		Expect(err).To(BeNil())
+
+		context := newContext("--routing-config", configPath, "--slack-bot-token", "xoxb-token")
+		routes, err := getRoutes(context, messageTemplate)
+		Expect(err).To(BeNil())
+
+		data := &slack.TemplateData{Path: "/builds/<ios>/a&b.png"}
+		deliveries, err = routes.deliveries(data.Path, newNotification(data, nil), data)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Should render the route's template unescaped for a routed mattermost destination", func() {
+		Expect(deliveries[0].Name).To(Equal("ios mattermost://chat.example.com"))
+		Expect(deliveries[0].Notification.Text).To(Equal("iOS /builds/<ios>/a&b.png"))
+	})
+
+	It("Should render the route's template for mrkdwn in a routed slack channel", func() {
+		Expect(deliveries[1].Name).To(Equal("ios slack #ios"))
+		Expect(deliveries[1].Notification.Text).To(Equal("iOS /builds/&lt;ios&gt;/a&amp;b.png"))
+	})
+
+	It("Should render the message template unescaped for a route without a template", func() {
+		Expect(deliveries[2].Name).To(Equal("everything teams://example.webhook.office.com"))
+		Expect(deliveries[2].Notification.Text).To(Equal("Saved /builds/<ios>/a&b.png"))
+	})
+})
@@ -0,0 +1,133 @@
+package routing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config maps dropbox paths to where their uploads are announced. It is
+// read from YAML, or JSON which is also YAML
+type Config struct {
+	Routes []*Route `yaml:"routes"`
+}
+
+// Route announces the uploads whose path matches its Glob or Regex
+type Route struct {
+	// Name identifies the route in the summary of the notifications
+	Name string `yaml:"name"`
+
+	// Glob matches the path, where ** matches across folders, * within
+	// a folder and ? a single character. Dropbox paths are not case
+	// sensitive, so neither is the match
+	Glob string `yaml:"glob"`
+
+	// Regex matches the path when there is no Glob
+	Regex string `yaml:"regex"`
+
+	// Notify lists destinations written like --notify
+	Notify []string `yaml:"notify"`
+
+	// Channels lists the slack channels posted to with the bot token
+	Channels []string `yaml:"channels"`
+
+	// Template, or the file named by TemplateFile, renders the message
+	// for the route's destinations
+	Template     string `yaml:"template"`
+	TemplateFile string `yaml:"template_file"`
+
+	// Mentions are people or groups notified along with the message,
+	// written in the destination's own syntax, such as <!subteam^ID>
+	Mentions []string `yaml:"mentions"`
+
+	// Final stops matching the routes after this one when it matches
+	Final bool `yaml:"final"`
+
+	pattern *regexp.Regexp
+}
+
+// Load reads the config from the file at path
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse reads the config, and checks that every route has a pattern
+// that compiles
+func Parse(data []byte) (*Config, error) {
+	config := &Config{}
+	err := yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, route := range config.Routes {
+		if route.Name == "" {
+			route.Name = fmt.Sprintf("route %v", i+1)
+		}
+
+		switch {
+		case route.Glob != "" && route.Regex != "":
+			return nil, fmt.Errorf("Routing %v has both a glob and a regex, expected one", route.Name)
+		case route.Glob != "":
+			route.pattern, err = GlobRegexp(route.Glob)
+		case route.Regex != "":
+			route.pattern, err = regexp.Compile(route.Regex)
+		default:
+			return nil, fmt.Errorf("Routing %v has neither a glob nor a regex", route.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Routing %v has an invalid pattern: %v", route.Name, err)
+		}
+
+		if route.Template != "" && route.TemplateFile != "" {
+			return nil, fmt.Errorf("Routing %v has both a template and a template_file, expected one", route.Name)
+		}
+	}
+	return config, nil
+}
+
+// Match returns the routes matching the dropbox path, in order, up to
+// the first matching Final route
+func (config *Config) Match(dropboxPath string) []*Route {
+	var routes []*Route
+	for _, route := range config.Routes {
+		if !route.pattern.MatchString(dropboxPath) {
+			continue
+		}
+
+		routes = append(routes, route)
+		if route.Final {
+			break
+		}
+	}
+	return routes
+}
+
+// GlobRegexp converts the glob into a regexp matching whole paths,
+// ignoring case
+func GlobRegexp(glob string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("(?i)^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
@@ -0,0 +1,119 @@
+package routing_test
+
+import (
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/routing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func names(routes []*routing.Route) []string {
+	var names []string
+	for _, route := range routes {
+		names = append(names, route.Name)
+	}
+	return names
+}
+
+var _ = Describe("Config", func() {
+	var config *routing.Config
+	var err error
+
+	Describe("Parse", func() {
+		BeforeEach(func() {
+			config, err = routing.Parse([]byte(`
+routes:
+  - name: ios
+    glob: /failures/ios/**
+    channels: ["#ios"]
+    mentions: ["<!subteam^S0IOS>"]
+    template: "iOS failure: <{{.PublicURL}}|{{.Path}}>"
+  - name: web
+    regex: ^/failures/web/.*\.png$
+    notify: ["teams://example.webhook.office.com/hook"]
+    final: true
+  - glob: /failures/**
+    channels: ["#failures"]
+`))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+
+		It("Should have read the routes", func() {
+			Expect(config.Routes).To(HaveLen(3))
+			ios := config.Routes[0]
+			Expect(ios.Channels).To(Equal([]string{"#ios"}))
+			Expect(ios.Mentions).To(Equal([]string{"<!subteam^S0IOS>"}))
+			Expect(ios.Template).To(Equal("iOS failure: <{{.PublicURL}}|{{.Path}}>"))
+			Expect(config.Routes[1].Notify).To(Equal([]string{"teams://example.webhook.office.com/hook"}))
+		})
+
+		It("Should have named the unnamed route", func() {
+			Expect(config.Routes[2].Name).To(Equal("route 3"))
+		})
+
+		It("Should match every route for the path", func() {
+			Expect(names(config.Match("/failures/ios/smoke/example.png"))).To(Equal([]string{"ios", "route 3"}))
+		})
+
+		It("Should stop at a final route", func() {
+			Expect(names(config.Match("/failures/web/example.png"))).To(Equal([]string{"web"}))
+		})
+
+		It("Should skip routes that do not match", func() {
+			Expect(names(config.Match("/failures/android/example.png"))).To(Equal([]string{"route 3"}))
+			Expect(config.Match("/successes/example.png")).To(BeEmpty())
+		})
+
+		It("Should match globs without regard to case", func() {
+			Expect(names(config.Match("/Failures/iOS/Example.png"))).To(Equal([]string{"ios", "route 3"}))
+		})
+	})
+
+	Describe("when a route has no pattern", func() {
+		BeforeEach(func() {
+			config, err = routing.Parse([]byte(`{"routes": [{"name": "everything", "channels": ["#all"]}]}`))
+		})
+
+		It("Should have an error", func() {
+			Expect(err).To(MatchError("Routing everything has neither a glob nor a regex"))
+		})
+	})
+
+	Describe("when a route has an invalid regex", func() {
+		BeforeEach(func() {
+			config, err = routing.Parse([]byte(`{"routes": [{"regex": "(unclosed"}]}`))
+		})
+
+		It("Should have an error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
+
+var _ = Describe("GlobRegexp", func() {
+	It("Should match within a folder with *", func() {
+		pattern, _ := routing.GlobRegexp("/failures/*.png")
+		Expect(pattern.MatchString("/failures/example.png")).To(BeTrue())
+		Expect(pattern.MatchString("/failures/ios/example.png")).To(BeFalse())
+	})
+
+	It("Should match across folders with **", func() {
+		pattern, _ := routing.GlobRegexp("/failures/**.png")
+		Expect(pattern.MatchString("/failures/ios/smoke/example.png")).To(BeTrue())
+	})
+
+	It("Should match a single character with ?", func() {
+		pattern, _ := routing.GlobRegexp("/build-?.png")
+		Expect(pattern.MatchString("/build-7.png")).To(BeTrue())
+		Expect(pattern.MatchString("/build-42.png")).To(BeFalse())
+	})
+
+	It("Should treat other characters literally", func() {
+		pattern, _ := routing.GlobRegexp("/a+b (1).png")
+		Expect(pattern.MatchString("/a+b (1).png")).To(BeTrue())
+		Expect(pattern.MatchString("/aab (1).png")).To(BeFalse())
+	})
+})
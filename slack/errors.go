@@ -0,0 +1,80 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// InvalidPayloadError is returned when slack could not read the message,
+// such as malformed blocks
+type InvalidPayloadError struct {
+	Err error
+}
+
+func (err *InvalidPayloadError) Error() string {
+	return fmt.Sprintf("Slack rejected the message payload: %v", err.Err)
+}
+
+func (err *InvalidPayloadError) Unwrap() error {
+	return err.Err
+}
+
+// ChannelNotFoundError is returned when the webhook's channel no longer
+// exists
+type ChannelNotFoundError struct {
+	Err error
+}
+
+func (err *ChannelNotFoundError) Error() string {
+	return fmt.Sprintf("Slack could not find the webhook's channel: %v", err.Err)
+}
+
+func (err *ChannelNotFoundError) Unwrap() error {
+	return err.Err
+}
+
+// NoServiceError is returned when the webhook was disabled, removed or
+// never existed
+type NoServiceError struct {
+	Err error
+}
+
+func (err *NoServiceError) Error() string {
+	return fmt.Sprintf("Slack webhook is disabled or does not exist: %v", err.Err)
+}
+
+func (err *NoServiceError) Unwrap() error {
+	return err.Err
+}
+
+// ActionProhibitedError is returned when an admin has restricted posting
+// to the webhook's channel
+type ActionProhibitedError struct {
+	Err error
+}
+
+func (err *ActionProhibitedError) Error() string {
+	return fmt.Sprintf("Slack does not allow posting to the webhook's channel: %v", err.Err)
+}
+
+func (err *ActionProhibitedError) Unwrap() error {
+	return err.Err
+}
+
+// decodeWebhookError converts the error strings slack documents for
+// webhooks into typed errors, and leaves the rest as they are
+func decodeWebhookError(err *notifier.StatusError) error {
+	switch strings.TrimSpace(err.Body) {
+	case "invalid_payload":
+		return &InvalidPayloadError{err}
+	case "channel_not_found":
+		return &ChannelNotFoundError{err}
+	case "no_service":
+		return &NoServiceError{err}
+	case "action_prohibited":
+		return &ActionProhibitedError{err}
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package slack
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// File is a file uploaded to slack with files.upload
+type File struct {
+	// Content is the file itself
+	Content []byte
+
+	// Filename is the name slack shows for the file
+	Filename string
+
+	// Title defaults to the Filename
+	Title string
+
+	// InitialComment is posted as a message along with the file
+	InitialComment string
+
+	// Channels the file is shared to. It defaults to the channel the
+	// slack instance posts to
+	Channels []string
+
+	// ThreadTS shares the file as a reply in the thread of the message
+	// with that timestamp
+	ThreadTS string
+}
+
+// FileResult identifies an uploaded file
+type FileResult struct {
+	// ID is the ID slack gave the file
+	ID string
+
+	// Name is the filename slack stored
+	Name string
+
+	// Permalink is a link to the file that requires signing in to slack
+	Permalink string
+
+	// Channel and TS identify the message the file was shared in. When
+	// it was shared to several channels, they identify one of them
+	Channel string
+	TS      string
+}
+
+type fileShare struct {
+	TS string `json:"ts"`
+}
+
+type filesUploadResponse struct {
+	File struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		Permalink string `json:"permalink"`
+		Shares    struct {
+			Public  map[string][]fileShare `json:"public"`
+			Private map[string][]fileShare `json:"private"`
+		} `json:"shares"`
+	} `json:"file"`
+}
+
+// UploadFile uploads the file with files.upload and shares it to the
+// channels, so it can be seen without access to dropbox
+func (slack *webAPISlack) UploadFile(file *File) (*FileResult, error) {
+	channels := file.Channels
+	if len(channels) == 0 {
+		channels = []string{slack.channel}
+	}
+
+	title := file.Title
+	if title == "" {
+		title = file.Filename
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fields := []struct{ name, value string }{
+		{"channels", strings.Join(channels, ",")},
+		{"filename", file.Filename},
+		{"title", title},
+		{"initial_comment", file.InitialComment},
+		{"thread_ts", file.ThreadTS},
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		err := writer.WriteField(field.name, field.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	part, err := writer.CreateFormFile("file", file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	_, err = part.Write(file.Content)
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var response filesUploadResponse
+	err = slack.options.Retry.Do(func() error {
+		request, err := http.NewRequest("POST", slack.methodURL("files.upload"), bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		request.Header.Set("Authorization", "Bearer "+slack.token)
+
+		return slack.do("files.upload", request, &response)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FileResult{ID: response.File.ID, Name: response.File.Name, Permalink: response.File.Permalink}
+	for _, shares := range []map[string][]fileShare{response.File.Shares.Public, response.File.Shares.Private} {
+		for channel, channelShares := range shares {
+			if len(channelShares) > 0 {
+				result.Channel = channel
+				result.TS = channelShares[0].TS
+			}
+		}
+	}
+	return result, nil
+}
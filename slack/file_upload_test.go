@@ -0,0 +1,123 @@
+package slack_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UploadFile", func() {
+	var sut slack.WebAPI
+	var server *httptest.Server
+	var lastRequest *http.Request
+	var formValues map[string]string
+	var fileContent string
+	var fileName string
+	var result *slack.FileResult
+	var err error
+
+	BeforeEach(func() {
+		formValues = map[string]string{}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lastRequest = r
+			r.ParseMultipartForm(1 << 20)
+			for name, values := range r.MultipartForm.Value {
+				formValues[name] = values[0]
+			}
+			file, header, _ := r.FormFile("file")
+			data, _ := ioutil.ReadAll(file)
+			fileContent = string(data)
+			fileName = header.Filename
+
+			w.Write([]byte(`{"ok": true, "file": {"id": "F0S43PZDF", "name": "example.png", "permalink": "https://example.slack.com/files/example.png", "shares": {"public": {"C024BE91L": [{"ts": "1451747045.000200"}]}}}}`))
+		}))
+
+		sut = slack.NewWebAPI("xoxb-token", "#failures", slack.Options{BaseURL: server.URL})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("with a title and comment", func() {
+		BeforeEach(func() {
+			result, err = sut.UploadFile(&slack.File{
+				Content:        []byte("png-bytes"),
+				Filename:       "example.png",
+				Title:          "Latest upload",
+				InitialComment: "ios-smoke failed",
+			})
+		})
+
+		It("Should have called files.upload with the bot token", func() {
+			Expect(lastRequest.URL.Path).To(Equal("/files.upload"))
+			Expect(lastRequest.Header.Get("Authorization")).To(Equal("Bearer xoxb-token"))
+		})
+
+		It("Should have sent the file", func() {
+			Expect(fileName).To(Equal("example.png"))
+			Expect(fileContent).To(Equal("png-bytes"))
+		})
+
+		It("Should have sent the fields, sharing to the default channel", func() {
+			Expect(formValues).To(Equal(map[string]string{
+				"channels":        "#failures",
+				"filename":        "example.png",
+				"title":           "Latest upload",
+				"initial_comment": "ios-smoke failed",
+			}))
+		})
+
+		It("Should return the uploaded file", func() {
+			Expect(result).To(Equal(&slack.FileResult{
+				ID:        "F0S43PZDF",
+				Name:      "example.png",
+				Permalink: "https://example.slack.com/files/example.png",
+				Channel:   "C024BE91L",
+				TS:        "1451747045.000200",
+			}))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("with channels and no title", func() {
+		BeforeEach(func() {
+			result, err = sut.UploadFile(&slack.File{
+				Content:  []byte("png-bytes"),
+				Filename: "example.png",
+				Channels: []string{"C024BE91L", "C024BE91M"},
+			})
+		})
+
+		It("Should have shared to the channels, titled by the filename", func() {
+			Expect(formValues).To(Equal(map[string]string{
+				"channels": "C024BE91L,C024BE91M",
+				"filename": "example.png",
+				"title":    "example.png",
+			}))
+		})
+	})
+
+	Describe("in a thread", func() {
+		BeforeEach(func() {
+			result, err = sut.UploadFile(&slack.File{
+				Content:  []byte("png-bytes"),
+				Filename: "example.png",
+				ThreadTS: "1451747040.000100",
+			})
+		})
+
+		It("Should have shared the file in the thread", func() {
+			Expect(formValues["thread_ts"]).To(Equal("1451747040.000100"))
+		})
+	})
+})
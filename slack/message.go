@@ -0,0 +1,258 @@
+package slack
+
+import "encoding/json"
+
+// Message is a slack message. Text is shown in notifications, and in the
+// channel when there are no blocks
+type Message struct {
+	Text        string        `json:"text,omitempty"`
+	Blocks      []Block       `json:"blocks,omitempty"`
+	Attachments []*Attachment `json:"attachments,omitempty"`
+
+	// UnfurlLinks and UnfurlMedia control slack's previews of links in
+	// the text. Nil leaves slack's default
+	UnfurlLinks *bool `json:"unfurl_links,omitempty"`
+	UnfurlMedia *bool `json:"unfurl_media,omitempty"`
+
+	// ThreadTS makes the message a reply in the thread of the message
+	// with that timestamp. ReplyBroadcast also shows the reply in the
+	// channel
+	ThreadTS       string `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool   `json:"reply_broadcast,omitempty"`
+}
+
+// Block is a Block Kit layout block: a SectionBlock, ImageBlock,
+// ContextBlock, DividerBlock or ActionsBlock
+type Block interface {
+	block()
+}
+
+// ContextElement is an element of a ContextBlock: a Text or an
+// ImageElement
+type ContextElement interface {
+	contextElement()
+}
+
+// Text is a Block Kit text object
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// NewText constructs a text object formatted with slack's mrkdwn
+func NewText(text string) *Text {
+	return &Text{Type: "mrkdwn", Text: text}
+}
+
+// NewPlainText constructs a text object shown as is
+func NewPlainText(text string) *Text {
+	return &Text{Type: "plain_text", Text: text}
+}
+
+// SectionBlock shows text, optionally with fields laid out in two columns
+type SectionBlock struct {
+	Type   string  `json:"type"`
+	Text   *Text   `json:"text,omitempty"`
+	Fields []*Text `json:"fields,omitempty"`
+}
+
+// NewSectionBlock constructs a section with the text and fields
+func NewSectionBlock(text *Text, fields ...*Text) *SectionBlock {
+	return &SectionBlock{Type: "section", Text: text, Fields: fields}
+}
+
+// ImageBlock shows an image. The URL must serve the image itself
+type ImageBlock struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+	Title    *Text  `json:"title,omitempty"`
+}
+
+// NewImageBlock constructs an image block. An empty title is left out
+func NewImageBlock(imageURL, altText, title string) *ImageBlock {
+	imageBlock := &ImageBlock{Type: "image", ImageURL: imageURL, AltText: altText}
+	if title != "" {
+		imageBlock.Title = NewPlainText(title)
+	}
+	return imageBlock
+}
+
+// ImageElement is a small image within a ContextBlock
+type ImageElement struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+// NewImageElement constructs an image element for a context block
+func NewImageElement(imageURL, altText string) *ImageElement {
+	return &ImageElement{Type: "image", ImageURL: imageURL, AltText: altText}
+}
+
+// ContextBlock shows small text and images, such as build details
+type ContextBlock struct {
+	Type     string           `json:"type"`
+	Elements []ContextElement `json:"elements"`
+}
+
+// NewContextBlock constructs a context block with the elements
+func NewContextBlock(elements ...ContextElement) *ContextBlock {
+	return &ContextBlock{Type: "context", Elements: elements}
+}
+
+// DividerBlock draws a line between blocks
+type DividerBlock struct {
+	Type string `json:"type"`
+}
+
+// NewDividerBlock constructs a divider
+func NewDividerBlock() *DividerBlock {
+	return &DividerBlock{Type: "divider"}
+}
+
+// Button is a button that opens a URL
+type Button struct {
+	Type  string `json:"type"`
+	Text  *Text  `json:"text"`
+	URL   string `json:"url"`
+	Style string `json:"style,omitempty"`
+}
+
+// NewLinkButton constructs a button that opens the url
+func NewLinkButton(text, url string) *Button {
+	return &Button{Type: "button", Text: NewPlainText(text), URL: url}
+}
+
+// ActionsBlock shows a row of buttons
+type ActionsBlock struct {
+	Type     string    `json:"type"`
+	Elements []*Button `json:"elements"`
+}
+
+// NewActionsBlock constructs an actions block with the buttons
+func NewActionsBlock(buttons ...*Button) *ActionsBlock {
+	return &ActionsBlock{Type: "actions", Elements: buttons}
+}
+
+// Attachment is a legacy message attachment, shown with a colored bar
+type Attachment struct {
+	Fallback   string             `json:"fallback,omitempty"`
+	Color      string             `json:"color,omitempty"`
+	Pretext    string             `json:"pretext,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	TitleLink  string             `json:"title_link,omitempty"`
+	Text       string             `json:"text,omitempty"`
+	Fields     []*AttachmentField `json:"fields,omitempty"`
+	ImageURL   string             `json:"image_url,omitempty"`
+	ThumbURL   string             `json:"thumb_url,omitempty"`
+	Footer     string             `json:"footer,omitempty"`
+	FooterIcon string             `json:"footer_icon,omitempty"`
+	Ts         int64              `json:"ts,omitempty"`
+}
+
+// AttachmentField is a title and value shown in an attachment. Short
+// fields are laid out side by side
+type AttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func (*SectionBlock) block() {}
+func (*ImageBlock) block()   {}
+func (*ContextBlock) block() {}
+func (*DividerBlock) block() {}
+func (*ActionsBlock) block() {}
+
+func (*Text) contextElement()         {}
+func (*ImageElement) contextElement() {}
+
+// RawBlock is a block of a type this package does not model, kept as
+// the JSON it was decoded from
+type RawBlock json.RawMessage
+
+// MarshalJSON returns the block's JSON unchanged
+func (block RawBlock) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(block).MarshalJSON()
+}
+
+func (RawBlock) block() {}
+
+// UnmarshalJSON decodes a message, including its blocks, from a slack
+// message payload
+func (message *Message) UnmarshalJSON(data []byte) error {
+	type messageWithoutBlocks Message
+	var wrap struct {
+		*messageWithoutBlocks
+		Blocks []json.RawMessage `json:"blocks"`
+	}
+	wrap.messageWithoutBlocks = (*messageWithoutBlocks)(message)
+	if err := json.Unmarshal(data, &wrap); err != nil {
+		return err
+	}
+
+	message.Blocks = nil
+	for _, data := range wrap.Blocks {
+		block, err := unmarshalBlock(data)
+		if err != nil {
+			return err
+		}
+		message.Blocks = append(message.Blocks, block)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a context block and each of its elements
+func (contextBlock *ContextBlock) UnmarshalJSON(data []byte) error {
+	var wrap struct {
+		Type     string            `json:"type"`
+		Elements []json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &wrap); err != nil {
+		return err
+	}
+
+	contextBlock.Type = wrap.Type
+	contextBlock.Elements = nil
+	for _, data := range wrap.Elements {
+		var element ContextElement = &Text{}
+		if typeOf(data) == "image" {
+			element = &ImageElement{}
+		}
+		if err := json.Unmarshal(data, element); err != nil {
+			return err
+		}
+		contextBlock.Elements = append(contextBlock.Elements, element)
+	}
+	return nil
+}
+
+func unmarshalBlock(data json.RawMessage) (Block, error) {
+	var block Block
+	switch typeOf(data) {
+	case "section":
+		block = &SectionBlock{}
+	case "image":
+		block = &ImageBlock{}
+	case "context":
+		block = &ContextBlock{}
+	case "divider":
+		block = &DividerBlock{}
+	case "actions":
+		block = &ActionsBlock{}
+	default:
+		return RawBlock(data), nil
+	}
+
+	err := json.Unmarshal(data, block)
+	return block, err
+}
+
+func typeOf(data json.RawMessage) string {
+	var typed struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(data, &typed)
+	return typed.Type
+}
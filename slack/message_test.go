@@ -0,0 +1,110 @@
+package slack_test
+
+import (
+	"encoding/json"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func toJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	Expect(err).To(BeNil())
+	return string(data)
+}
+
+var _ = Describe("Message", func() {
+	Describe("with every kind of block", func() {
+		var message *slack.Message
+
+		BeforeEach(func() {
+			unfurl := false
+			message = &slack.Message{
+				Text: "Build failed",
+				Blocks: []slack.Block{
+					slack.NewSectionBlock(slack.NewText("*Build failed*"), slack.NewText("*Job*\nios-smoke"), slack.NewText("*Build*\n42")),
+					slack.NewImageBlock("https://dropbox.biz/example.png?raw=1", "example.png", "Screenshot"),
+					slack.NewContextBlock(slack.NewImageElement("https://example.com/icon.png", "ci"), slack.NewPlainText("2016-01-02 15:04")),
+					slack.NewDividerBlock(),
+					slack.NewActionsBlock(slack.NewLinkButton("Open in Dropbox", "https://dropbox.biz/example.png")),
+				},
+				UnfurlLinks: &unfurl,
+				UnfurlMedia: &unfurl,
+			}
+		})
+
+		It("Should serialize to Block Kit JSON", func() {
+			Expect(toJSON(message)).To(MatchJSON(`{
+				"text": "Build failed",
+				"blocks": [
+					{
+						"type": "section",
+						"text": {"type": "mrkdwn", "text": "*Build failed*"},
+						"fields": [
+							{"type": "mrkdwn", "text": "*Job*\nios-smoke"},
+							{"type": "mrkdwn", "text": "*Build*\n42"}
+						]
+					},
+					{
+						"type": "image",
+						"image_url": "https://dropbox.biz/example.png?raw=1",
+						"alt_text": "example.png",
+						"title": {"type": "plain_text", "text": "Screenshot"}
+					},
+					{
+						"type": "context",
+						"elements": [
+							{"type": "image", "image_url": "https://example.com/icon.png", "alt_text": "ci"},
+							{"type": "plain_text", "text": "2016-01-02 15:04"}
+						]
+					},
+					{"type": "divider"},
+					{
+						"type": "actions",
+						"elements": [
+							{"type": "button", "text": {"type": "plain_text", "text": "Open in Dropbox"}, "url": "https://dropbox.biz/example.png"}
+						]
+					}
+				],
+				"unfurl_links": false,
+				"unfurl_media": false
+			}`))
+		})
+	})
+
+	Describe("with an attachment", func() {
+		var message *slack.Message
+
+		BeforeEach(func() {
+			message = &slack.Message{
+				Attachments: []*slack.Attachment{{
+					Fallback: "Build failed",
+					Color:    "danger",
+					Title:    "ios-smoke #42",
+					Fields: []*slack.AttachmentField{
+						{Title: "Branch", Value: "master", Short: true},
+					},
+					ImageURL: "https://dropbox.biz/example.png?raw=1",
+					Footer:   "image-upload-to-dropbox-and-post-to-slack",
+					Ts:       1451747045,
+				}},
+			}
+		})
+
+		It("Should serialize to attachment JSON and leave out what is unset", func() {
+			Expect(toJSON(message)).To(MatchJSON(`{
+				"attachments": [{
+					"fallback": "Build failed",
+					"color": "danger",
+					"title": "ios-smoke #42",
+					"fields": [{"title": "Branch", "value": "master", "short": true}],
+					"image_url": "https://dropbox.biz/example.png?raw=1",
+					"footer": "image-upload-to-dropbox-and-post-to-slack",
+					"ts": 1451747045
+				}]
+			}`))
+		})
+	})
+})
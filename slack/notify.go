@@ -0,0 +1,42 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// NewNotificationMessage constructs a message showing the notification's
+// text, image and facts
+func NewNotificationMessage(notification *notifier.Notification) *Message {
+	text := notification.Text
+	if text == "" {
+		text = fmt.Sprintf("<%v|%v>", notification.URL, EscapeText(notification.Title))
+	}
+	if len(notification.Mentions) > 0 {
+		text = strings.Join(notification.Mentions, " ") + " " + text
+	}
+
+	message := &Message{Text: text, Blocks: []Block{NewSectionBlock(NewText(text))}}
+	if notification.ImageURL != "" {
+		message = NewImageMessage(text, Image{URL: notification.ImageURL, Title: notification.Title})
+	}
+
+	if len(notification.Facts) > 0 {
+		var fields []*Text
+		for _, fact := range notification.Facts {
+			fields = append(fields, NewText(fmt.Sprintf("*%v*\n%v", EscapeText(fact.Name), EscapeText(fact.Value))))
+		}
+		message.Blocks = append(message.Blocks, &SectionBlock{Type: "section", Fields: fields})
+	}
+	return message
+}
+
+func (slack *webhookSlack) Notify(notification *notifier.Notification) error {
+	return slack.PostMessage(NewNotificationMessage(notification))
+}
+
+func (slack *webAPISlack) Notify(notification *notifier.Notification) error {
+	return slack.PostMessage(NewNotificationMessage(notification))
+}
@@ -1,44 +1,109 @@
 package slack
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
-	"net/http"
+	"errors"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
 )
 
 // Slack is the interface for interacting with the Slack API
 type Slack interface {
+	// Notify posts a message built from the notification
+	notifier.Notifier
+
 	Post(text string) error
+
+	// PostImage posts the text with the image shown inline below it
+	PostImage(text string, image Image) error
+
+	// PostMessage posts a message built from blocks and attachments
+	PostMessage(message *Message) error
 }
 
-type webhookSlack struct {
-	webhookURI string
+// Image is an image shown inline in a message. The URL must serve the
+// image itself, not a page that contains it
+type Image struct {
+	URL     string
+	AltText string
+	Title   string
 }
 
-type slackMessage struct {
-	Text string `json:"text"`
+// Options configures the behavior of a Slack instance
+type Options struct {
+	notifier.HTTPOptions
+
+	// BaseURL is where the Web API methods are found. It defaults to
+	// DefaultBaseURL and is only used by NewWebAPI
+	BaseURL string
+
+	// Username, IconURL and IconEmoji override how a bot's messages
+	// appear. They are only used by NewWebAPI
+	Username  string
+	IconURL   string
+	IconEmoji string
+}
+
+type webhookSlack struct {
+	webhookURI string
+	options    Options
 }
 
 // New constructs a new slack instance using a webhook
 func New(webhookURI string) Slack {
-	return &webhookSlack{webhookURI}
+	return NewWithOptions(webhookURI, Options{})
+}
+
+// NewWithOptions constructs a new slack instance using a webhook and the
+// given options
+func NewWithOptions(webhookURI string, options Options) Slack {
+	return &webhookSlack{webhookURI, withDefaults(options)}
+}
+
+// withDefaults fills in the options that were left empty
+func withDefaults(options Options) Options {
+	options.HTTPOptions = options.HTTPOptions.WithDefaults()
+	if options.BaseURL == "" {
+		options.BaseURL = DefaultBaseURL
+	}
+	return options
 }
 
 func (slack *webhookSlack) Post(text string) error {
-	message := &slackMessage{Text: text}
+	return slack.PostMessage(&Message{Text: text})
+}
+
+func (slack *webhookSlack) PostImage(text string, image Image) error {
+	return slack.PostMessage(NewImageMessage(text, image))
+}
+
+func (slack *webhookSlack) PostMessage(message *Message) error {
 	messageBytes, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	resp, err := http.Post(slack.webhookURI, "application/json", bytes.NewBuffer(messageBytes))
-	if err != nil {
-		return err
+	_, err = slack.options.Post(slack.webhookURI, "application/json", messageBytes)
+	var statusError *notifier.StatusError
+	if errors.As(err, &statusError) {
+		statusError.Destination = "slack"
+		return decodeWebhookError(statusError)
 	}
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("Non 200 status received from slack: %v, %v", resp.StatusCode, resp.Body)
+	return err
+}
+
+// NewImageMessage constructs a message with the text above the image
+func NewImageMessage(text string, image Image) *Message {
+	altText := image.AltText
+	if altText == "" {
+		altText = image.Title
 	}
 
-	return nil
+	return &Message{
+		Text: text,
+		Blocks: []Block{
+			NewSectionBlock(NewText(text)),
+			NewImageBlock(image.URL, altText, image.Title),
+		},
+	}
 }
@@ -0,0 +1,290 @@
+package slack_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Slack", func() {
+	var sut slack.Slack
+	var server *httptest.Server
+	var statusCodes []int
+	var requestBodies []string
+	var errorBody string
+	var sleeps []time.Duration
+	var err error
+
+	BeforeEach(func() {
+		statusCodes = nil
+		requestBodies = nil
+		errorBody = "ok"
+		sleeps = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requestBodies = append(requestBodies, string(body))
+
+			statusCode := 200
+			if len(requestBodies) <= len(statusCodes) {
+				statusCode = statusCodes[len(requestBodies)-1]
+			}
+			if statusCode == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "3")
+			}
+			w.WriteHeader(statusCode)
+			if statusCode == 200 {
+				w.Write([]byte("ok"))
+				return
+			}
+			w.Write([]byte(errorBody))
+		}))
+
+		policy := retry.Policy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Second,
+			Sleep: func(duration time.Duration) {
+				sleeps = append(sleeps, duration)
+			},
+		}
+		sut = slack.NewWithOptions(server.URL, slack.Options{HTTPOptions: notifier.HTTPOptions{Retry: policy}})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("sut.Post(text)", func() {
+		Describe("when slack accepts the message", func() {
+			BeforeEach(func() {
+				err = sut.Post("Hello")
+			})
+
+			It("Should have posted the text", func() {
+				Expect(requestBodies).To(Equal([]string{`{"text":"Hello"}`}))
+			})
+
+			It("Should not have an error", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("when slack rate limits the first post", func() {
+			BeforeEach(func() {
+				statusCodes = []int{http.StatusTooManyRequests}
+				err = sut.Post("Hello")
+			})
+
+			It("Should have waited for the Retry-After and posted again", func() {
+				Expect(requestBodies).To(HaveLen(2))
+				Expect(sleeps).To(Equal([]time.Duration{3 * time.Second}))
+			})
+
+			It("Should not have an error", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("when slack keeps failing", func() {
+			BeforeEach(func() {
+				statusCodes = []int{500, 502, 503}
+				err = sut.Post("Hello")
+			})
+
+			It("Should have given up after MaxAttempts", func() {
+				Expect(requestBodies).To(HaveLen(3))
+			})
+
+			It("Should have an error", func() {
+				Expect(err).NotTo(BeNil())
+			})
+		})
+
+		Describe("when slack rejects the message", func() {
+			BeforeEach(func() {
+				statusCodes = []int{400}
+				err = sut.Post("Hello")
+			})
+
+			It("Should not have retried", func() {
+				Expect(requestBodies).To(HaveLen(1))
+			})
+
+			It("Should have an error that includes the response", func() {
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(Equal("Non 2xx status received from slack: 400, ok"))
+			})
+		})
+	})
+
+	Describe("when slack responds with a documented error", func() {
+		It("Should decode invalid_payload", func() {
+			statusCodes, errorBody = []int{400}, "invalid_payload"
+			err = sut.Post("Hello")
+
+			var invalidPayloadError *slack.InvalidPayloadError
+			Expect(errors.As(err, &invalidPayloadError)).To(BeTrue())
+			Expect(err.Error()).To(Equal("Slack rejected the message payload: Non 2xx status received from slack: 400, invalid_payload"))
+		})
+
+		It("Should decode channel_not_found", func() {
+			statusCodes, errorBody = []int{404}, "channel_not_found"
+			err = sut.Post("Hello")
+
+			var channelNotFoundError *slack.ChannelNotFoundError
+			Expect(errors.As(err, &channelNotFoundError)).To(BeTrue())
+		})
+
+		It("Should decode no_service", func() {
+			statusCodes, errorBody = []int{404}, "no_service"
+			err = sut.Post("Hello")
+
+			var noServiceError *slack.NoServiceError
+			Expect(errors.As(err, &noServiceError)).To(BeTrue())
+		})
+
+		It("Should decode action_prohibited without retrying", func() {
+			statusCodes, errorBody = []int{403}, "action_prohibited"
+			err = sut.Post("Hello")
+
+			var actionProhibitedError *slack.ActionProhibitedError
+			Expect(errors.As(err, &actionProhibitedError)).To(BeTrue())
+			Expect(requestBodies).To(HaveLen(1))
+		})
+	})
+
+	Describe("when slack takes longer than the client's timeout", func() {
+		var slowServer *httptest.Server
+
+		BeforeEach(func() {
+			slowServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(100 * time.Millisecond)
+			}))
+
+			httpClient := &http.Client{Timeout: 10 * time.Millisecond}
+			sut = slack.NewWithOptions(slowServer.URL, slack.Options{HTTPOptions: notifier.HTTPOptions{HTTPClient: httpClient}})
+			err = sut.Post("Hello")
+		})
+
+		AfterEach(func() {
+			slowServer.Close()
+		})
+
+		It("Should have a timeout error", func() {
+			var netError net.Error
+			Expect(errors.As(err, &netError)).To(BeTrue())
+			Expect(netError.Timeout()).To(BeTrue())
+		})
+	})
+
+	Describe("sut.PostImage(text, image)", func() {
+		BeforeEach(func() {
+			image := slack.Image{URL: "https://dropbox.biz/example.png?raw=1", AltText: "example.png", Title: "Latest upload"}
+			err = sut.PostImage("<https://dropbox.biz/example.png|Click Here>", image)
+		})
+
+		It("Should have posted the text with an image block", func() {
+			Expect(requestBodies).To(HaveLen(1))
+			Expect(requestBodies[0]).To(MatchJSON(`{
+				"text": "<https://dropbox.biz/example.png|Click Here>",
+				"blocks": [
+					{"type": "section", "text": {"type": "mrkdwn", "text": "<https://dropbox.biz/example.png|Click Here>"}},
+					{
+						"type": "image",
+						"image_url": "https://dropbox.biz/example.png?raw=1",
+						"alt_text": "example.png",
+						"title": {"type": "plain_text", "text": "Latest upload"}
+					}
+				]
+			}`))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("sut.PostMessage(message)", func() {
+		BeforeEach(func() {
+			message := &slack.Message{
+				Text:        "Build failed",
+				Attachments: []*slack.Attachment{{Color: "danger", Text: "ios-smoke #42"}},
+			}
+			err = sut.PostMessage(message)
+		})
+
+		It("Should have posted the message", func() {
+			Expect(requestBodies).To(HaveLen(1))
+			Expect(requestBodies[0]).To(MatchJSON(`{
+				"text": "Build failed",
+				"attachments": [{"color": "danger", "text": "ios-smoke #42"}]
+			}`))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("sut.Notify(notification)", func() {
+		BeforeEach(func() {
+			err = sut.Notify(&notifier.Notification{
+				Title:    "example.png",
+				Text:     "<https://dropbox.biz/example.png|Click Here>",
+				URL:      "https://dropbox.biz/example.png",
+				ImageURL: "https://dropbox.biz/example.png?raw=1",
+				Facts:    []notifier.Fact{{Name: "Job", Value: "ios-smoke"}},
+			})
+		})
+
+		It("Should have posted the text, image and facts", func() {
+			Expect(requestBodies).To(HaveLen(1))
+			Expect(requestBodies[0]).To(MatchJSON(`{
+				"text": "<https://dropbox.biz/example.png|Click Here>",
+				"blocks": [
+					{"type": "section", "text": {"type": "mrkdwn", "text": "<https://dropbox.biz/example.png|Click Here>"}},
+					{
+						"type": "image",
+						"image_url": "https://dropbox.biz/example.png?raw=1",
+						"alt_text": "example.png",
+						"title": {"type": "plain_text", "text": "example.png"}
+					},
+					{"type": "section", "fields": [{"type": "mrkdwn", "text": "*Job*\nios-smoke"}]}
+				]
+			}`))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("sut.Notify(notification) with mentions", func() {
+		BeforeEach(func() {
+			err = sut.Notify(&notifier.Notification{
+				Title:    "example.png",
+				URL:      "https://dropbox.biz/example.png",
+				Mentions: []string{"<!subteam^S0IOS>", "<@U024BE7LH>"},
+			})
+		})
+
+		It("Should have mentioned them before the link", func() {
+			Expect(requestBodies[0]).To(MatchJSON(`{
+				"text": "<!subteam^S0IOS> <@U024BE7LH> <https://dropbox.biz/example.png|example.png>",
+				"blocks": [
+					{"type": "section", "text": {"type": "mrkdwn", "text": "<!subteam^S0IOS> <@U024BE7LH> <https://dropbox.biz/example.png|example.png>"}}
+				]
+			}`))
+		})
+	})
+})
@@ -0,0 +1,121 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData holds the values available to message templates
+type TemplateData struct {
+	PublicURL string
+	DirectURL string
+	Path      string
+	Size      int64
+	MIMEType  string
+	Width     int
+	Height    int
+	Timestamp time.Time
+	Hostname  string
+
+	// Rev, ServerModified and ContentHash identify the uploaded revision
+	// of the file, as dropbox describes it
+	Rev            string
+	ServerModified time.Time
+	ContentHash    string
+
+	// Visibility and Expires describe the shared link. Expires is nil
+	// for links that never expire
+	Visibility string
+	Expires    *time.Time
+
+	// Unchanged is true when the upload was skipped because dropbox
+	// already had the same content
+	Unchanged bool
+
+	// Fields holds the user supplied key=value pairs
+	Fields map[string]string
+}
+
+// Template renders messages. A template whose text is a JSON object
+// renders a whole message payload, any other renders the message text
+type Template struct {
+	tmpl   *template.Template
+	isJSON bool
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// EscapeText escapes the characters slack's mrkdwn treats as control
+// characters, so that the text is shown as is
+func EscapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// ParseTemplate parses a text/template. Inside JSON templates, strings
+// should be inserted with the json function, as in {{json .Path}}, so
+// that they are quoted
+func ParseTemplate(text string) (*Template, error) {
+	funcs := template.FuncMap{
+		"escape": EscapeText,
+		"json": func(value interface{}) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}
+
+	tmpl, err := template.New("message").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	isJSON := strings.HasPrefix(strings.TrimSpace(text), "{")
+	return &Template{tmpl, isJSON}, nil
+}
+
+// Render evaluates the template with data. The values that come from
+// outside, the path, hostname and fields, are escaped for mrkdwn first
+func (messageTemplate *Template) Render(data *TemplateData) (*Message, error) {
+	return messageTemplate.render(data, EscapeText)
+}
+
+// RenderUnescaped evaluates the template with data as is, and with the
+// escape function left out, for destinations other than slack that
+// escape the text for their own format
+func (messageTemplate *Template) RenderUnescaped(data *TemplateData) (*Message, error) {
+	return messageTemplate.render(data, func(text string) string { return text })
+}
+
+func (messageTemplate *Template) render(data *TemplateData, escape func(string) string) (*Message, error) {
+	escaped := *data
+	escaped.Path = escape(data.Path)
+	escaped.Hostname = escape(data.Hostname)
+	escaped.Fields = make(map[string]string, len(data.Fields))
+	for key, value := range data.Fields {
+		escaped.Fields[key] = escape(value)
+	}
+
+	tmpl, err := messageTemplate.tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	err = tmpl.Funcs(template.FuncMap{"escape": escape}).Execute(&rendered, &escaped)
+	if err != nil {
+		return nil, err
+	}
+
+	if !messageTemplate.isJSON {
+		return &Message{Text: rendered.String()}, nil
+	}
+
+	message := &Message{}
+	err = json.Unmarshal(rendered.Bytes(), message)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
@@ -0,0 +1,118 @@
+package slack_test
+
+import (
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template", func() {
+	var data *slack.TemplateData
+	var message *slack.Message
+	var err error
+
+	BeforeEach(func() {
+		data = &slack.TemplateData{
+			PublicURL: "https://dropbox.biz/example.png?dl=0",
+			DirectURL: "https://dropbox.biz/example.png?raw=1",
+			Path:      "/failures/<ios>/example.png",
+			Size:      68,
+			Width:     1,
+			Height:    1,
+			Timestamp: time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC),
+			Hostname:  "ci-runner",
+			Fields:    map[string]string{"job": "ios & web", "build": "42"},
+		}
+	})
+
+	Describe("EscapeText", func() {
+		It("Should escape the mrkdwn control characters", func() {
+			Expect(slack.EscapeText("<a> & <b>")).To(Equal("&lt;a&gt; &amp; &lt;b&gt;"))
+		})
+	})
+
+	Describe("a text template", func() {
+		BeforeEach(func() {
+			messageTemplate, parseErr := slack.ParseTemplate("<{{.PublicURL}}|{{.Path}}> {{.Width}}x{{.Height}} {{.Fields.job}} #{{.Fields.build}} at {{.Timestamp.Format \"15:04\"}}")
+			Expect(parseErr).To(BeNil())
+			message, err = messageTemplate.Render(data)
+		})
+
+		It("Should render the text with the user supplied values escaped", func() {
+			Expect(message.Text).To(Equal("<https://dropbox.biz/example.png?dl=0|/failures/&lt;ios&gt;/example.png> 1x1 ios &amp; web #42 at 15:04"))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("a text template rendered unescaped", func() {
+		BeforeEach(func() {
+			messageTemplate, parseErr := slack.ParseTemplate("Saved {{.Path}} for {{.Fields.job}}, {{escape .Fields.job}}")
+			Expect(parseErr).To(BeNil())
+			message, err = messageTemplate.RenderUnescaped(data)
+		})
+
+		It("Should render the user supplied values as they are", func() {
+			Expect(message.Text).To(Equal("Saved /failures/<ios>/example.png for ios & web, ios & web"))
+		})
+	})
+
+	Describe("a text template using a field that was not supplied", func() {
+		BeforeEach(func() {
+			messageTemplate, parseErr := slack.ParseTemplate("branch: {{.Fields.branch}}")
+			Expect(parseErr).To(BeNil())
+			message, err = messageTemplate.Render(data)
+		})
+
+		It("Should render it empty", func() {
+			Expect(message.Text).To(Equal("branch: "))
+		})
+	})
+
+	Describe("a JSON template", func() {
+		BeforeEach(func() {
+			messageTemplate, parseErr := slack.ParseTemplate(`{
+				"text": {{json .Fields.job}},
+				"blocks": [
+					{"type": "section", "text": {"type": "mrkdwn", "text": {{json .Path}}}},
+					{"type": "image", "image_url": {{json .DirectURL}}, "alt_text": "screenshot"},
+					{"type": "header", "text": {"type": "plain_text", "text": "Build"}}
+				]
+			}`)
+			Expect(parseErr).To(BeNil())
+			message, err = messageTemplate.Render(data)
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+
+		It("Should render the whole message", func() {
+			Expect(message.Text).To(Equal("ios &amp; web"))
+			Expect(message.Blocks).To(HaveLen(3))
+			Expect(message.Blocks[0]).To(Equal(slack.NewSectionBlock(slack.NewText("/failures/&lt;ios&gt;/example.png"))))
+			Expect(message.Blocks[1]).To(Equal(slack.NewImageBlock("https://dropbox.biz/example.png?raw=1", "screenshot", "")))
+		})
+
+		It("Should keep blocks it does not model as they were", func() {
+			Expect(toJSON(message.Blocks[2])).To(MatchJSON(`{"type": "header", "text": {"type": "plain_text", "text": "Build"}}`))
+		})
+	})
+
+	Describe("a JSON template that renders invalid JSON", func() {
+		BeforeEach(func() {
+			messageTemplate, parseErr := slack.ParseTemplate(`{"text": {{.Fields.job}}}`)
+			Expect(parseErr).To(BeNil())
+			message, err = messageTemplate.Render(data)
+		})
+
+		It("Should have an error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,183 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// DefaultBaseURL is the address of slack's Web API
+const DefaultBaseURL = "https://slack.com/api/"
+
+// WebAPI is a Slack that posts with a bot token through the Web API,
+// which tells where each message ended up
+type WebAPI interface {
+	Slack
+
+	// PostMessageWithResult posts a message like PostMessage, and returns
+	// the channel and timestamp that identify the posted message
+	PostMessageWithResult(message *Message) (*PostResult, error)
+
+	// UpdateMessage replaces the message posted at ts in the channel.
+	// When that message was deleted, the error satisfies
+	// IsMessageNotFound
+	UpdateMessage(channel, ts string, message *Message) (*PostResult, error)
+
+	// UploadFile uploads the file itself to slack, for readers who
+	// cannot open dropbox links
+	UploadFile(file *File) (*FileResult, error)
+}
+
+// PostResult identifies a posted message
+type PostResult struct {
+	// Channel is the ID of the channel the message was posted to
+	Channel string
+
+	// TS is the timestamp slack uses as the message ID
+	TS string
+}
+
+// APIError is returned when a Web API method responds with ok: false
+type APIError struct {
+	Method string
+	Code   string
+}
+
+func (err *APIError) Error() string {
+	return fmt.Sprintf("Slack %v failed: %v", err.Method, err.Code)
+}
+
+// IsMessageNotFound is true when err is slack reporting that the message
+// to update no longer exists
+func IsMessageNotFound(err error) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.Code == "message_not_found"
+}
+
+type webAPISlack struct {
+	token   string
+	channel string
+	options Options
+}
+
+type chatPostMessage struct {
+	*Message
+	Channel   string `json:"channel"`
+	Username  string `json:"username,omitempty"`
+	IconURL   string `json:"icon_url,omitempty"`
+	IconEmoji string `json:"icon_emoji,omitempty"`
+}
+
+type chatUpdate struct {
+	*Message
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+type chatPostMessageResponse struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// NewWebAPI constructs a new slack instance that posts to the channel
+// using a bot token
+func NewWebAPI(token, channel string, options Options) WebAPI {
+	return &webAPISlack{token, channel, withDefaults(options)}
+}
+
+func (slack *webAPISlack) Post(text string) error {
+	return slack.PostMessage(&Message{Text: text})
+}
+
+func (slack *webAPISlack) PostImage(text string, image Image) error {
+	return slack.PostMessage(NewImageMessage(text, image))
+}
+
+func (slack *webAPISlack) PostMessage(message *Message) error {
+	_, err := slack.PostMessageWithResult(message)
+	return err
+}
+
+func (slack *webAPISlack) PostMessageWithResult(message *Message) (*PostResult, error) {
+	request := &chatPostMessage{
+		Message:   message,
+		Channel:   slack.channel,
+		Username:  slack.options.Username,
+		IconURL:   slack.options.IconURL,
+		IconEmoji: slack.options.IconEmoji,
+	}
+
+	var response chatPostMessageResponse
+	err := slack.call("chat.postMessage", request, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostResult{Channel: response.Channel, TS: response.TS}, nil
+}
+
+func (slack *webAPISlack) UpdateMessage(channel, ts string, message *Message) (*PostResult, error) {
+	request := &chatUpdate{Message: message, Channel: channel, TS: ts}
+
+	var response chatPostMessageResponse
+	err := slack.call("chat.update", request, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostResult{Channel: response.Channel, TS: response.TS}, nil
+}
+
+// call invokes a Web API method with a JSON body, and decodes the
+// response into result once slack reports it ok
+func (slack *webAPISlack) call(method string, body interface{}, result interface{}) error {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	return slack.options.Retry.Do(func() error {
+		request, err := http.NewRequest("POST", slack.methodURL(method), bytes.NewReader(bodyBytes))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Content-Type", "application/json; charset=utf-8")
+		request.Header.Set("Authorization", "Bearer "+slack.token)
+
+		return slack.do(method, request, result)
+	})
+}
+
+func (slack *webAPISlack) do(method string, request *http.Request, result interface{}) error {
+	respBody, err := notifier.Do(slack.options.HTTPClient, request)
+	var statusError *notifier.StatusError
+	if errors.As(err, &statusError) {
+		statusError.Destination = "slack"
+	}
+	if err != nil {
+		return err
+	}
+
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	err = json.Unmarshal(respBody, &status)
+	if err != nil {
+		return err
+	}
+	if !status.OK {
+		return &APIError{Method: method, Code: status.Error}
+	}
+
+	return json.Unmarshal(respBody, result)
+}
+
+func (slack *webAPISlack) methodURL(method string) string {
+	return strings.TrimSuffix(slack.options.BaseURL, "/") + "/" + method
+}
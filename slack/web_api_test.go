@@ -0,0 +1,175 @@
+package slack_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebAPI", func() {
+	var sut slack.WebAPI
+	var server *httptest.Server
+	var response string
+	var lastRequest *http.Request
+	var lastRequestBody string
+	var result *slack.PostResult
+	var err error
+
+	BeforeEach(func() {
+		response = `{"ok": true, "channel": "C024BE91L", "ts": "1451747045.000200"}`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			lastRequest = r
+			lastRequestBody = string(body)
+			w.Write([]byte(response))
+		}))
+
+		options := slack.Options{BaseURL: server.URL, Username: "uploader", IconEmoji: ":camera:"}
+		sut = slack.NewWebAPI("xoxb-token", "#failures", options)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("sut.PostMessageWithResult(message)", func() {
+		Describe("when slack accepts the message", func() {
+			BeforeEach(func() {
+				result, err = sut.PostMessageWithResult(&slack.Message{Text: "Hello"})
+			})
+
+			It("Should have called chat.postMessage with the bot token", func() {
+				Expect(lastRequest.URL.Path).To(Equal("/chat.postMessage"))
+				Expect(lastRequest.Header.Get("Authorization")).To(Equal("Bearer xoxb-token"))
+			})
+
+			It("Should have posted to the channel with the overrides", func() {
+				Expect(lastRequestBody).To(MatchJSON(`{
+					"text": "Hello",
+					"channel": "#failures",
+					"username": "uploader",
+					"icon_emoji": ":camera:"
+				}`))
+			})
+
+			It("Should return the channel ID and ts", func() {
+				Expect(result).To(Equal(&slack.PostResult{Channel: "C024BE91L", TS: "1451747045.000200"}))
+			})
+
+			It("Should not have an error", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("when replying in a thread", func() {
+			BeforeEach(func() {
+				message := &slack.Message{Text: "Hello", ThreadTS: "1451747040.000100", ReplyBroadcast: true}
+				result, err = sut.PostMessageWithResult(message)
+			})
+
+			It("Should have posted the reply to the thread", func() {
+				Expect(lastRequestBody).To(MatchJSON(`{
+					"text": "Hello",
+					"thread_ts": "1451747040.000100",
+					"reply_broadcast": true,
+					"channel": "#failures",
+					"username": "uploader",
+					"icon_emoji": ":camera:"
+				}`))
+			})
+		})
+
+		Describe("when slack responds with ok: false", func() {
+			BeforeEach(func() {
+				response = `{"ok": false, "error": "channel_not_found"}`
+				result, err = sut.PostMessageWithResult(&slack.Message{Text: "Hello"})
+			})
+
+			It("Should have an APIError", func() {
+				Expect(err).To(Equal(&slack.APIError{Method: "chat.postMessage", Code: "channel_not_found"}))
+			})
+
+			It("Should have a nil result", func() {
+				Expect(result).To(BeNil())
+			})
+		})
+	})
+
+	Describe("sut.UpdateMessage(channel, ts, message)", func() {
+		Describe("when the message exists", func() {
+			BeforeEach(func() {
+				result, err = sut.UpdateMessage("C024BE91L", "1451747045.000200", &slack.Message{Text: "Hello again"})
+			})
+
+			It("Should have called chat.update for the message", func() {
+				Expect(lastRequest.URL.Path).To(Equal("/chat.update"))
+				Expect(lastRequestBody).To(MatchJSON(`{
+					"text": "Hello again",
+					"channel": "C024BE91L",
+					"ts": "1451747045.000200"
+				}`))
+			})
+
+			It("Should return the channel ID and ts", func() {
+				Expect(result).To(Equal(&slack.PostResult{Channel: "C024BE91L", TS: "1451747045.000200"}))
+			})
+
+			It("Should not have an error", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("when the message was deleted", func() {
+			BeforeEach(func() {
+				response = `{"ok": false, "error": "message_not_found"}`
+				result, err = sut.UpdateMessage("C024BE91L", "1451747045.000200", &slack.Message{Text: "Hello again"})
+			})
+
+			It("Should have an error that is message not found", func() {
+				Expect(slack.IsMessageNotFound(err)).To(BeTrue())
+			})
+		})
+
+		Describe("when the channel is missing", func() {
+			BeforeEach(func() {
+				response = `{"ok": false, "error": "channel_not_found"}`
+				result, err = sut.UpdateMessage("C024BE91L", "1451747045.000200", &slack.Message{Text: "Hello again"})
+			})
+
+			It("Should have an error that is not message not found", func() {
+				Expect(err).NotTo(BeNil())
+				Expect(slack.IsMessageNotFound(err)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("sut.PostImage(text, image)", func() {
+		BeforeEach(func() {
+			err = sut.PostImage("Hello", slack.Image{URL: "https://dropbox.biz/example.png?raw=1", Title: "example.png"})
+		})
+
+		It("Should have posted an image block to the channel", func() {
+			Expect(lastRequestBody).To(MatchJSON(`{
+				"text": "Hello",
+				"blocks": [
+					{"type": "section", "text": {"type": "mrkdwn", "text": "Hello"}},
+					{
+						"type": "image",
+						"image_url": "https://dropbox.biz/example.png?raw=1",
+						"alt_text": "example.png",
+						"title": {"type": "plain_text", "text": "example.png"}
+					}
+				],
+				"channel": "#failures",
+				"username": "uploader",
+				"icon_emoji": ":camera:"
+			}`))
+		})
+	})
+})
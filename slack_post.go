@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+)
+
+// Values of --slack-upload
+const (
+	slackUploadAlongside = "alongside"
+	slackUploadInstead   = "instead"
+)
+
+// slackPost is how an upload is posted to slack, decided from the flags
+// before anything is uploaded
+type slackPost struct {
+	client      slack.Slack
+	channel     string
+	upload      string
+	uploadTitle string
+	threads     *threads
+	threadKey   string
+	broadcast   bool
+	latest      *latestMessages
+}
+
+// getSlackPost returns how to post to slack, or nil when neither a
+// webhook nor a bot token was given
+func getSlackPost(context *cli.Context, slackWebhook string) (*slackPost, error) {
+	if slackWebhook == "" && context.String("slack-bot-token") == "" {
+		return nil, nil
+	}
+
+	slackUpload, err := getSlackUpload(context)
+	if err != nil {
+		return nil, err
+	}
+
+	threads, err := getThreads(context)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := getLatestMessages(context)
+	if err != nil {
+		return nil, err
+	}
+
+	return &slackPost{
+		client:      getSlack(context, slackWebhook),
+		channel:     context.String("slack-channel"),
+		upload:      slackUpload,
+		uploadTitle: context.String("slack-upload-title"),
+		threads:     threads,
+		threadKey:   context.String("thread-key"),
+		broadcast:   context.Bool("thread-broadcast"),
+		latest:      latest,
+	}, nil
+}
+
+// name identifies slack in the summary of the notifications
+func (post *slackPost) name() string {
+	if _, ok := post.client.(slack.WebAPI); ok {
+		return "slack " + post.channel
+	}
+	return "slack webhook"
+}
+
+// post posts the message, in its thread or over the latest message when
+// asked to, and uploads the content when --slack-upload was given. The
+// content is nil when it was too large to keep for the upload
+func (post *slackPost) post(message *slack.Message, dropboxPath, filename string, content []byte) error {
+	if post.upload != "" && content == nil {
+		return fmt.Errorf("The image is larger than %v bytes, too large for --slack-upload", maxAttachmentSize)
+	}
+
+	var parent *postedMessage
+	if post.threads != nil {
+		var err error
+		parent, err = post.threads.find(post.threadKey)
+		if err != nil {
+			return err
+		}
+	}
+	if parent != nil {
+		message.ThreadTS = parent.TS
+		message.ReplyBroadcast = post.broadcast
+	}
+
+	posted := &slack.PostResult{}
+	var err error
+	if post.latest != nil {
+		posted, err = post.latest.post(post.client.(slack.WebAPI), dropboxPath, withUpdatedAt(message, time.Now()))
+	} else if post.upload != slackUploadInstead {
+		posted, err = postMessage(post.client, message)
+	}
+	if err != nil {
+		return err
+	}
+
+	if post.upload != "" {
+		file := &slack.File{
+			Content:  content,
+			Filename: filename,
+			Title:    post.uploadTitle,
+			ThreadTS: message.ThreadTS,
+		}
+		if post.upload == slackUploadInstead {
+			file.InitialComment = message.Text
+		}
+
+		fileResult, err := post.client.(slack.WebAPI).UploadFile(file)
+		if err != nil {
+			return err
+		}
+		debug("uploaded file %v to slack", fileResult.ID)
+
+		if posted.TS == "" {
+			posted = &slack.PostResult{Channel: fileResult.Channel, TS: fileResult.TS}
+		}
+	}
+
+	if post.threads != nil && parent == nil && posted.TS != "" {
+		err = post.threads.save(post.threadKey, postedMessage{Channel: posted.Channel, TS: posted.TS})
+		if err != nil {
+			return err
+		}
+		debug("started thread %v for %v", posted.TS, post.threadKey)
+	}
+	return nil
+}
+
+// postMessage posts the message, and returns where it ended up when
+// slack tells us
+func postMessage(slackClient slack.Slack, message *slack.Message) (*slack.PostResult, error) {
+	webAPI, ok := slackClient.(slack.WebAPI)
+	if !ok {
+		return &slack.PostResult{}, slackClient.PostMessage(message)
+	}
+
+	postResult, err := webAPI.PostMessageWithResult(message)
+	if err != nil {
+		return nil, err
+	}
+	debug("posted message %v to channel %v", postResult.TS, postResult.Channel)
+	return postResult, nil
+}
+
+// getSlackUpload validates --slack-upload, which needs the Web API to
+// upload files
+func getSlackUpload(context *cli.Context) (string, error) {
+	slackUpload := context.String("slack-upload")
+	switch slackUpload {
+	case "":
+		return "", nil
+	case slackUploadAlongside, slackUploadInstead:
+	default:
+		return "", fmt.Errorf("Invalid --slack-upload: %v, expected alongside or instead", slackUpload)
+	}
+
+	if context.String("slack-bot-token") == "" {
+		return "", fmt.Errorf("--slack-upload requires --slack-bot-token")
+	}
+	return slackUpload, nil
+}
+
+// getSlack constructs a Web API slack when a bot token was given, and a
+// webhook slack otherwise
+func getSlack(context *cli.Context, slackWebhook string) slack.Slack {
+	options := slack.Options{
+		HTTPOptions: notifier.HTTPOptions{
+			Retry:      getRetryPolicy(context),
+			HTTPClient: &http.Client{Timeout: context.Duration("slack-timeout")},
+		},
+		BaseURL:   context.String("slack-base-url"),
+		Username:  context.String("slack-username"),
+		IconURL:   context.String("slack-icon-url"),
+		IconEmoji: context.String("slack-icon-emoji"),
+	}
+
+	slackBotToken := context.String("slack-bot-token")
+	if slackBotToken == "" {
+		return slack.NewWithOptions(slackWebhook, options)
+	}
+	return slack.NewWebAPI(slackBotToken, context.String("slack-channel"), options)
+}
@@ -0,0 +1,48 @@
+// +build !windows
+
+package state_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/state"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Store locking", func() {
+	var dir string
+	var path string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "state")
+		Expect(err).To(BeNil())
+		path = filepath.Join(dir, "threads.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Should wait for another run holding the lock before changing the file", func() {
+		lockFile, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+		Expect(err).To(BeNil())
+		defer lockFile.Close()
+		Expect(syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX)).To(Succeed())
+
+		done := make(chan error)
+		go func() {
+			done <- state.New(path).Set("build-42", thread{TS: "1451747045.000200"})
+		}()
+		Consistently(done, 100*time.Millisecond).ShouldNot(Receive())
+
+		Expect(syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)).To(Succeed())
+		Eventually(done).Should(Receive(BeNil()))
+	})
+})
@@ -0,0 +1,150 @@
+package state
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Store is a small JSON file of values kept between runs, such as where
+// an earlier run posted its message. A missing file is an empty store.
+// Changes take a lock on a .lock file next to it, so runs sharing the
+// store do not lose each other's changes
+type Store struct {
+	path string
+}
+
+// New constructs a Store backed by the file at path
+func New(path string) *Store {
+	return &Store{path}
+}
+
+// Get decodes the value stored under key into value, and reports
+// whether there was one
+func (store *Store) Get(key string, value interface{}) (bool, error) {
+	values, err := store.read()
+	if err != nil {
+		return false, err
+	}
+
+	data, ok := values[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, value)
+}
+
+// Set stores value under key, replacing the file so a reader never sees
+// it half written
+func (store *Store) Set(key string, value interface{}) error {
+	unlock, err := store.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	values, err := store.read()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	values[key] = data
+
+	return store.write(values)
+}
+
+// Delete removes the value stored under key
+func (store *Store) Delete(key string) error {
+	unlock, err := store.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	values, err := store.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[key]; !ok {
+		return nil
+	}
+
+	delete(values, key)
+	return store.write(values)
+}
+
+// lock waits for the lock on the store, and returns the func that
+// releases it
+func (store *Store) lock() (func(), error) {
+	err := os.MkdirAll(filepath.Dir(store.path), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(store.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	err = lockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+func (store *Store) read() (map[string]json.RawMessage, error) {
+	values := map[string]json.RawMessage{}
+
+	data, err := ioutil.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return values, nil
+	}
+
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (store *Store) write(values map[string]json.RawMessage) error {
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(store.path)
+	file, err := ioutil.TempFile(dir, filepath.Base(store.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), store.path)
+}
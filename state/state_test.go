@@ -0,0 +1,126 @@
+package state_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/state"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type thread struct {
+	Channel string
+	TS      string
+}
+
+var _ = Describe("Store", func() {
+	var sut *state.Store
+	var dir string
+	var path string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "state")
+		Expect(err).To(BeNil())
+
+		path = filepath.Join(dir, "nested", "threads.json")
+		sut = state.New(path)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("when the file does not exist", func() {
+		It("Should not find a value", func() {
+			var value thread
+			ok, err := sut.Get("build-42", &value)
+			Expect(ok).To(BeFalse())
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when a value was set", func() {
+		BeforeEach(func() {
+			Expect(sut.Set("build-42", thread{Channel: "C024BE91L", TS: "1451747045.000200"})).To(BeNil())
+			Expect(sut.Set("build-43", thread{Channel: "C024BE91L", TS: "1451747046.000200"})).To(BeNil())
+		})
+
+		It("Should find the value from a new store on the same file", func() {
+			var value thread
+			ok, err := state.New(path).Get("build-42", &value)
+			Expect(ok).To(BeTrue())
+			Expect(err).To(BeNil())
+			Expect(value).To(Equal(thread{Channel: "C024BE91L", TS: "1451747045.000200"}))
+		})
+
+		It("Should not have left temporary files behind", func() {
+			entries, err := ioutil.ReadDir(filepath.Dir(path))
+			Expect(err).To(BeNil())
+
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			Expect(names).To(ConsistOf("threads.json", "threads.json.lock"))
+		})
+
+		Describe("and then deleted", func() {
+			BeforeEach(func() {
+				Expect(sut.Delete("build-42")).To(BeNil())
+			})
+
+			It("Should not find the deleted value", func() {
+				var value thread
+				ok, _ := sut.Get("build-42", &value)
+				Expect(ok).To(BeFalse())
+			})
+
+			It("Should still find the other value", func() {
+				var value thread
+				ok, _ := sut.Get("build-43", &value)
+				Expect(ok).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("when runs set values at the same time", func() {
+		It("Should keep every value", func() {
+			var wait sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wait.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wait.Done()
+					Expect(state.New(path).Set(fmt.Sprintf("build-%v", i), thread{TS: fmt.Sprint(i)})).To(Succeed())
+				}(i)
+			}
+			wait.Wait()
+
+			for i := 0; i < 50; i++ {
+				var value thread
+				ok, err := sut.Get(fmt.Sprintf("build-%v", i), &value)
+				Expect(err).To(BeNil())
+				Expect(ok).To(BeTrue(), "build-%v was lost", i)
+			}
+		})
+	})
+
+	Describe("when the file is not JSON", func() {
+		BeforeEach(func() {
+			os.MkdirAll(filepath.Dir(path), 0755)
+			ioutil.WriteFile(path, []byte("not json"), 0644)
+		})
+
+		It("Should have an error", func() {
+			var value thread
+			_, err := sut.Get("build-42", &value)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
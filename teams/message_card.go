@@ -0,0 +1,99 @@
+package teams
+
+import (
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// MessageCard is an Office 365 connector card. Summary is shown in
+// notifications and is required when there is no Text
+type MessageCard struct {
+	Type            string     `json:"@type"`
+	Context         string     `json:"@context"`
+	Summary         string     `json:"summary,omitempty"`
+	ThemeColor      string     `json:"themeColor,omitempty"`
+	Title           string     `json:"title,omitempty"`
+	Text            string     `json:"text,omitempty"`
+	Sections        []*Section `json:"sections,omitempty"`
+	PotentialAction []*Action  `json:"potentialAction,omitempty"`
+}
+
+// Section groups facts and images within a card
+type Section struct {
+	Text   string   `json:"text,omitempty"`
+	Facts  []*Fact  `json:"facts,omitempty"`
+	Images []*Image `json:"images,omitempty"`
+}
+
+// Fact is a name and value pair shown as a table row
+type Fact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Image is an image shown in a section. The URL must serve the image
+// itself
+type Image struct {
+	Image string `json:"image"`
+	Title string `json:"title,omitempty"`
+}
+
+// Action is a button on the card. Only OpenUri actions are supported
+type Action struct {
+	Type    string    `json:"@type"`
+	Name    string    `json:"name"`
+	Targets []*Target `json:"targets"`
+}
+
+// Target is where an OpenUri action goes on each OS
+type Target struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+// NewMessageCard constructs a card with the title and text
+func NewMessageCard(title, text string) *MessageCard {
+	summary := title
+	if summary == "" {
+		summary = text
+	}
+	return &MessageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Summary: summary,
+		Title:   title,
+		Text:    text,
+	}
+}
+
+// NewOpenURIAction constructs a button that opens the uri
+func NewOpenURIAction(name, uri string) *Action {
+	return &Action{Type: "OpenUri", Name: name, Targets: []*Target{{OS: "default", URI: uri}}}
+}
+
+// NewNotificationCard constructs a card showing the notification's
+// title, text, image and facts, with a button to open the upload
+func NewNotificationCard(notification *notifier.Notification) *MessageCard {
+	text := notification.Text
+	if len(notification.Mentions) > 0 {
+		text = strings.TrimSpace(strings.Join(notification.Mentions, " ") + " " + text)
+	}
+	card := NewMessageCard(notification.Title, text)
+
+	section := &Section{}
+	for _, fact := range notification.Facts {
+		section.Facts = append(section.Facts, &Fact{Name: fact.Name, Value: fact.Value})
+	}
+	if notification.ImageURL != "" {
+		section.Images = []*Image{{Image: notification.ImageURL, Title: notification.Title}}
+	}
+	if len(section.Facts) > 0 || len(section.Images) > 0 {
+		card.Sections = []*Section{section}
+	}
+
+	if notification.URL != "" {
+		card.PotentialAction = []*Action{NewOpenURIAction("Open", notification.URL)}
+	}
+	return card
+}
@@ -0,0 +1,47 @@
+package teams
+
+import (
+	"encoding/json"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// Teams posts to a Microsoft Teams channel through an Office 365
+// connector webhook
+type Teams interface {
+	// Notify posts a card built from the notification
+	notifier.Notifier
+
+	// PostCard posts the card to the channel
+	PostCard(card *MessageCard) error
+}
+
+// Options configures the behavior of a Teams instance
+type Options struct {
+	notifier.HTTPOptions
+}
+
+type webhookTeams struct {
+	webhookURI string
+	options    Options
+}
+
+// New constructs a new teams instance using a connector webhook
+func New(webhookURI string, options Options) Teams {
+	options.HTTPOptions = options.HTTPOptions.WithDefaults()
+	return &webhookTeams{webhookURI, options}
+}
+
+func (teams *webhookTeams) Notify(notification *notifier.Notification) error {
+	return teams.PostCard(NewNotificationCard(notification))
+}
+
+func (teams *webhookTeams) PostCard(card *MessageCard) error {
+	cardBytes, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+
+	_, err = teams.options.Post(teams.webhookURI, "application/json", cardBytes)
+	return err
+}
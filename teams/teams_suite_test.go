@@ -0,0 +1,13 @@
+package teams_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTeams(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Teams Suite")
+}
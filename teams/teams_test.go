@@ -0,0 +1,113 @@
+package teams_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/teams"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Teams", func() {
+	var sut teams.Teams
+	var server *httptest.Server
+	var statusCodes []int
+	var requestBodies []string
+	var err error
+
+	BeforeEach(func() {
+		statusCodes = nil
+		requestBodies = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requestBodies = append(requestBodies, string(body))
+
+			statusCode := 200
+			if len(requestBodies) <= len(statusCodes) {
+				statusCode = statusCodes[len(requestBodies)-1]
+			}
+			w.WriteHeader(statusCode)
+			w.Write([]byte("1"))
+		}))
+
+		policy := retry.Policy{MaxAttempts: 2, Sleep: func(time.Duration) {}}
+		sut = teams.New(server.URL, teams.Options{HTTPOptions: notifier.HTTPOptions{Retry: policy}})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("sut.Notify(notification)", func() {
+		BeforeEach(func() {
+			err = sut.Notify(&notifier.Notification{
+				Title:    "example.png",
+				Text:     "ios-smoke failed",
+				URL:      "https://dropbox.biz/example.png",
+				ImageURL: "https://dropbox.biz/example.png?raw=1",
+				Facts:    []notifier.Fact{{Name: "Job", Value: "ios-smoke"}},
+			})
+		})
+
+		It("Should have posted a MessageCard", func() {
+			Expect(requestBodies).To(HaveLen(1))
+			Expect(requestBodies[0]).To(MatchJSON(`{
+				"@type": "MessageCard",
+				"@context": "https://schema.org/extensions",
+				"summary": "example.png",
+				"title": "example.png",
+				"text": "ios-smoke failed",
+				"sections": [{
+					"facts": [{"name": "Job", "value": "ios-smoke"}],
+					"images": [{"image": "https://dropbox.biz/example.png?raw=1", "title": "example.png"}]
+				}],
+				"potentialAction": [{
+					"@type": "OpenUri",
+					"name": "Open",
+					"targets": [{"os": "default", "uri": "https://dropbox.biz/example.png"}]
+				}]
+			}`))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when teams fails once", func() {
+		BeforeEach(func() {
+			statusCodes = []int{502}
+			err = sut.PostCard(teams.NewMessageCard("example.png", "Hello"))
+		})
+
+		It("Should have retried", func() {
+			Expect(requestBodies).To(HaveLen(2))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when teams rejects the card", func() {
+		BeforeEach(func() {
+			statusCodes = []int{400}
+			err = sut.PostCard(teams.NewMessageCard("", ""))
+		})
+
+		It("Should not have retried", func() {
+			Expect(requestBodies).To(HaveLen(1))
+		})
+
+		It("Should have a StatusError", func() {
+			Expect(err).To(BeAssignableToTypeOf(&notifier.StatusError{}))
+		})
+	})
+})
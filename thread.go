@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/state"
+)
+
+// postedMessage identifies a message posted by an earlier run, such as
+// the parent of a --thread-key
+type postedMessage struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// threads keeps the parent message of each thread key. Keys are scoped
+// to the channel, since a parent is only found in its own channel
+type threads struct {
+	store   *state.Store
+	channel string
+}
+
+// getThreads returns the thread store for --thread-key, or nil when no
+// key was given
+func getThreads(context *cli.Context) (*threads, error) {
+	if context.String("thread-key") == "" {
+		return nil, nil
+	}
+	if context.String("slack-bot-token") == "" {
+		return nil, fmt.Errorf("--thread-key requires --slack-bot-token")
+	}
+
+	path, err := stateFile(context.String("thread-state-file"), "threads.json")
+	if err != nil {
+		return nil, err
+	}
+	return &threads{state.New(path), context.String("slack-channel")}, nil
+}
+
+// find returns the parent message of the thread key, or nil when the key
+// has not been posted yet
+func (threads *threads) find(key string) (*postedMessage, error) {
+	var parent postedMessage
+	ok, err := threads.store.Get(threads.storeKey(key), &parent)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &parent, nil
+}
+
+// save makes the message the parent of the thread key
+func (threads *threads) save(key string, parent postedMessage) error {
+	return threads.store.Set(threads.storeKey(key), parent)
+}
+
+func (threads *threads) storeKey(key string) string {
+	return threads.channel + "/" + key
+}
+
+// stateFile returns path, or the file with the given name in the user's
+// cache directory when path is empty
+func stateFile(path, name string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("Could not find a directory for %v: %v", name, err)
+	}
+	return filepath.Join(cacheDir, "image-upload-to-dropbox-and-post-to-slack", name), nil
+}
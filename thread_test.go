@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/slack"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/state"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("threads", func() {
+	var dir string
+	var webAPI *FakeWebAPI
+	var post *slackPost
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "threads")
+		Expect(err).To(BeNil())
+
+		webAPI = &FakeWebAPI{PostedTS: []string{"1.000100", "1.000200"}}
+		post = &slackPost{
+			client:    webAPI,
+			channel:   "#builds",
+			threads:   &threads{state.New(filepath.Join(dir, "threads.json")), "#builds"},
+			threadKey: "nightly",
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("when the thread key has not been posted", func() {
+		BeforeEach(func() {
+			err := post.post(&slack.Message{Text: "first"}, "/nightly.png", "nightly.png", nil)
+			Expect(err).To(BeNil())
+		})
+
+		It("Should post outside of a thread", func() {
+			Expect(webAPI.Posted[0].ThreadTS).To(BeEmpty())
+		})
+
+		It("Should save the message as the parent", func() {
+			parent, err := post.threads.find("nightly")
+			Expect(err).To(BeNil())
+			Expect(parent).To(Equal(&postedMessage{Channel: "C123", TS: "1.000100"}))
+		})
+
+		Describe("when it is posted again", func() {
+			BeforeEach(func() {
+				err := post.post(&slack.Message{Text: "second"}, "/nightly.png", "nightly.png", nil)
+				Expect(err).To(BeNil())
+			})
+
+			It("Should reply in the thread", func() {
+				Expect(webAPI.Posted[1].ThreadTS).To(Equal("1.000100"))
+			})
+
+			It("Should keep the first message as the parent", func() {
+				parent, err := post.threads.find("nightly")
+				Expect(err).To(BeNil())
+				Expect(parent.TS).To(Equal("1.000100"))
+			})
+		})
+	})
+
+	Describe("when the same key is used in another channel", func() {
+		BeforeEach(func() {
+			err := post.threads.save("nightly", postedMessage{Channel: "C123", TS: "1.000100"})
+			Expect(err).To(BeNil())
+			post.threads = &threads{post.threads.store, "#releases"}
+		})
+
+		It("Should not find the parent", func() {
+			parent, err := post.threads.find("nightly")
+			Expect(err).To(BeNil())
+			Expect(parent).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,167 @@
+package uploader
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// Base64Error is returned when base64 content has a character that
+// does not belong where it is. Offset counts every byte read, including
+// whitespace
+type Base64Error struct {
+	Offset int64
+	Char   byte
+	Reason string
+}
+
+func (err *Base64Error) Error() string {
+	return fmt.Sprintf("Invalid base64 at byte offset %v: %v %q", err.Offset, err.Reason, err.Char)
+}
+
+// TruncatedBase64Error is returned when base64 content ends with a
+// single character, which cannot encode a byte
+type TruncatedBase64Error struct {
+	Offset int64
+}
+
+func (err *TruncatedBase64Error) Error() string {
+	return fmt.Sprintf("Invalid base64 at byte offset %v: content ended in the middle of a byte", err.Offset)
+}
+
+// base64ReadSize is how much encoded content is read at a time
+const base64ReadSize = 32 * 1024
+
+// alphabet names the base64 alphabet content was found to use
+type alphabet int
+
+const (
+	alphabetUnknown alphabet = iota
+	alphabetStd
+	alphabetURL
+)
+
+// urlToStd translates the URL alphabet characters to the standard
+// alphabet they replace
+var urlToStd = map[byte]byte{'-': '+', '_': '/'}
+
+type base64Decoder struct {
+	source   io.Reader
+	in       []byte
+	out      []byte
+	quantum  [4]byte
+	n        int
+	offset   int64
+	padding  int
+	alphabet alphabet
+	err      error
+}
+
+// NewBase64Decoder decodes the base64 read from source as it is read,
+// so content of any size is decoded in a bounded amount of memory. It
+// accepts the standard and URL alphabets, with or without padding, and
+// skips whitespace and newlines
+func NewBase64Decoder(source io.Reader) io.Reader {
+	return &base64Decoder{source: source, in: make([]byte, base64ReadSize)}
+}
+
+func (decoder *base64Decoder) Read(p []byte) (int, error) {
+	for len(decoder.out) == 0 && decoder.err == nil {
+		decoder.fill()
+	}
+
+	n := copy(p, decoder.out)
+	decoder.out = decoder.out[n:]
+	if len(decoder.out) > 0 {
+		return n, nil
+	}
+	return n, decoder.err
+}
+
+// fill decodes the next read from source into out, or sets err
+func (decoder *base64Decoder) fill() {
+	n, err := decoder.source.Read(decoder.in)
+	decoder.out = decoder.out[:0]
+	for _, char := range decoder.in[:n] {
+		decodeErr := decoder.decodeChar(char)
+		if decodeErr != nil {
+			decoder.err = decodeErr
+			return
+		}
+		decoder.offset++
+	}
+
+	if err == io.EOF {
+		decoder.err = decoder.finish()
+		return
+	}
+	decoder.err = err
+}
+
+// decodeChar adds the char to the quantum, decoding the quantum once it
+// is complete
+func (decoder *base64Decoder) decodeChar(char byte) error {
+	switch char {
+	case ' ', '\t', '\r', '\n', '\f', '\v':
+		return nil
+	case '=':
+		if decoder.n < 2 || decoder.n+decoder.padding >= 4 {
+			return decoder.charError(char, "unexpected padding")
+		}
+		decoder.padding++
+		return nil
+	}
+
+	if decoder.padding > 0 {
+		return decoder.charError(char, "character after padding")
+	}
+
+	switch {
+	case char == '+' || char == '/':
+		if decoder.alphabet == alphabetURL {
+			return decoder.charError(char, "standard alphabet character in URL alphabet content")
+		}
+		decoder.alphabet = alphabetStd
+	case char == '-' || char == '_':
+		if decoder.alphabet == alphabetStd {
+			return decoder.charError(char, "URL alphabet character in standard alphabet content")
+		}
+		decoder.alphabet = alphabetURL
+		char = urlToStd[char]
+	case char >= 'A' && char <= 'Z', char >= 'a' && char <= 'z', char >= '0' && char <= '9':
+	default:
+		return decoder.charError(char, "illegal character")
+	}
+
+	decoder.quantum[decoder.n] = char
+	decoder.n++
+	if decoder.n == 4 {
+		decoder.decodeQuantum()
+	}
+	return nil
+}
+
+// decodeQuantum decodes the characters collected so far, which are
+// missing their padding when there are fewer than 4
+func (decoder *base64Decoder) decodeQuantum() {
+	var decoded [3]byte
+	n, _ := base64.RawStdEncoding.Decode(decoded[:], decoder.quantum[:decoder.n])
+	decoder.out = append(decoder.out, decoded[:n]...)
+	decoder.n = 0
+	decoder.padding = 0
+}
+
+// finish decodes a final quantum that was left unpadded or padded
+func (decoder *base64Decoder) finish() error {
+	if decoder.n == 1 {
+		return &TruncatedBase64Error{Offset: decoder.offset}
+	}
+	if decoder.n > 1 {
+		decoder.decodeQuantum()
+	}
+	return io.EOF
+}
+
+func (decoder *base64Decoder) charError(char byte, reason string) error {
+	return &Base64Error{Offset: decoder.offset, Char: char, Reason: reason}
+}
@@ -0,0 +1,115 @@
+package uploader_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewBase64Decoder", func() {
+	var data []byte
+
+	BeforeEach(func() {
+		data = make([]byte, 256)
+		for i := range data {
+			data[i] = byte(i)
+		}
+	})
+
+	decode := func(encoded string) ([]byte, error) {
+		return ioutil.ReadAll(uploader.NewBase64Decoder(strings.NewReader(encoded)))
+	}
+
+	It("Should decode the standard alphabet", func() {
+		decoded, err := decode(base64.StdEncoding.EncodeToString(data))
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(data))
+	})
+
+	It("Should decode the URL alphabet", func() {
+		decoded, err := decode(base64.URLEncoding.EncodeToString(data))
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(data))
+	})
+
+	It("Should decode every length without padding", func() {
+		for length := 0; length < 8; length++ {
+			decoded, err := decode(base64.RawStdEncoding.EncodeToString(data[:length]))
+			Expect(err).To(BeNil())
+			Expect(decoded).To(Equal(data[:length]))
+		}
+	})
+
+	It("Should skip whitespace and newlines", func() {
+		encoded := base64.StdEncoding.EncodeToString(data)
+		wrapped := ""
+		for len(encoded) > 76 {
+			wrapped += encoded[:76] + "\r\n"
+			encoded = encoded[76:]
+		}
+		wrapped += " \t" + encoded + "\n"
+
+		decoded, err := decode(wrapped)
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(data))
+	})
+
+	It("Should decode content read one byte at a time", func() {
+		encoded := base64.StdEncoding.EncodeToString(data)
+		decoded, err := ioutil.ReadAll(uploader.NewBase64Decoder(&oneByteReader{strings.NewReader(encoded)}))
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(data))
+	})
+
+	It("Should report the offset of an illegal character", func() {
+		_, err := decode("QUJD\nRE*G")
+
+		var base64Error *uploader.Base64Error
+		Expect(errors.As(err, &base64Error)).To(BeTrue())
+		Expect(base64Error.Offset).To(Equal(int64(7)))
+		Expect(base64Error.Char).To(Equal(byte('*')))
+		Expect(err.Error()).To(Equal(`Invalid base64 at byte offset 7: illegal character '*'`))
+	})
+
+	It("Should report a character after the padding", func() {
+		_, err := decode("QQ==QQ==")
+
+		var base64Error *uploader.Base64Error
+		Expect(errors.As(err, &base64Error)).To(BeTrue())
+		Expect(base64Error.Offset).To(Equal(int64(4)))
+	})
+
+	It("Should report mixed alphabets", func() {
+		_, err := decode("ab+/ab-_")
+
+		var base64Error *uploader.Base64Error
+		Expect(errors.As(err, &base64Error)).To(BeTrue())
+		Expect(base64Error.Offset).To(Equal(int64(6)))
+	})
+
+	It("Should report content that ends in the middle of a byte", func() {
+		_, err := decode("QUJDR")
+
+		var truncatedError *uploader.TruncatedBase64Error
+		Expect(errors.As(err, &truncatedError)).To(BeTrue())
+		Expect(truncatedError.Offset).To(Equal(int64(5)))
+	})
+})
+
+type oneByteReader struct {
+	reader io.Reader
+}
+
+func (reader *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return reader.reader.Read(p[:1])
+}
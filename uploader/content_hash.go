@@ -0,0 +1,68 @@
+package uploader
+
+import (
+	"crypto/sha256"
+	"hash"
+)
+
+// ContentHashBlockSize is the size of the blocks dropbox hashes
+// separately to compute a content hash
+const ContentHashBlockSize = 4 * 1024 * 1024
+
+type contentHash struct {
+	blockSums []byte
+	block     hash.Hash
+	inBlock   int
+}
+
+// NewContentHash returns a hash.Hash computing the dropbox content_hash:
+// the SHA-256 of the SHA-256 of each 4 MB block of the content
+func NewContentHash() hash.Hash {
+	return &contentHash{block: sha256.New()}
+}
+
+func (contentHash *contentHash) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := ContentHashBlockSize - contentHash.inBlock
+		if n > len(p) {
+			n = len(p)
+		}
+		contentHash.block.Write(p[:n])
+		contentHash.inBlock += n
+		p = p[n:]
+
+		if contentHash.inBlock == ContentHashBlockSize {
+			contentHash.blockSums = contentHash.block.Sum(contentHash.blockSums)
+			contentHash.block.Reset()
+			contentHash.inBlock = 0
+		}
+	}
+	return written, nil
+}
+
+// Sum appends the content hash to b. It does not change the state of
+// the hash, so more content may be written after it
+func (contentHash *contentHash) Sum(b []byte) []byte {
+	blockSums := contentHash.blockSums
+	if contentHash.inBlock > 0 {
+		blockSums = contentHash.block.Sum(blockSums[:len(blockSums):len(blockSums)])
+	}
+
+	sum := sha256.Sum256(blockSums)
+	return append(b, sum[:]...)
+}
+
+func (contentHash *contentHash) Reset() {
+	contentHash.blockSums = nil
+	contentHash.block.Reset()
+	contentHash.inBlock = 0
+}
+
+func (contentHash *contentHash) Size() int {
+	return sha256.Size
+}
+
+func (contentHash *contentHash) BlockSize() int {
+	return sha256.BlockSize
+}
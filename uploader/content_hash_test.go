@@ -0,0 +1,47 @@
+package uploader_test
+
+import (
+	"encoding/hex"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewContentHash", func() {
+	sum := func(writes ...[]byte) string {
+		contentHash := uploader.NewContentHash()
+		for _, data := range writes {
+			contentHash.Write(data)
+		}
+		return hex.EncodeToString(contentHash.Sum(nil))
+	}
+
+	It("Should hash the hash of content smaller than a block", func() {
+		Expect(sum([]byte("abc"))).To(Equal("4f8b42c22dd3729b519ba6f68d2da7cc5b2d606d05daed5ad5128cc03e6c6358"))
+	})
+
+	It("Should hash the hashes of each block, however the content was written", func() {
+		data := make([]byte, uploader.ContentHashBlockSize+10)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+
+		expected := "6faca9167a42713c588bf8e5919e373a24a52e72b99fe2a8763b90c81a924049"
+		Expect(sum(data)).To(Equal(expected))
+		Expect(sum(data[:100], data[100:uploader.ContentHashBlockSize+5], data[uploader.ContentHashBlockSize+5:])).To(Equal(expected))
+	})
+
+	It("Should hash nothing as the hash of no blocks", func() {
+		Expect(sum()).To(Equal("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+	})
+
+	It("Should not change when summed", func() {
+		contentHash := uploader.NewContentHash()
+		contentHash.Write([]byte("ab"))
+		contentHash.Sum(nil)
+		contentHash.Write([]byte("c"))
+		Expect(hex.EncodeToString(contentHash.Sum(nil))).To(Equal("4f8b42c22dd3729b519ba6f68d2da7cc5b2d606d05daed5ad5128cc03e6c6358"))
+	})
+})
@@ -0,0 +1,322 @@
+package uploader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	// register the decoders used to read image dimensions
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// SniffLen is how much of the content is read up front to tell its type
+// and dimensions. JPEG dimensions come after the metadata, which can be
+// long
+const SniffLen = 64 * 1024
+
+// ContentType describes the image found in the content
+type ContentType struct {
+	// MIMEType is empty when the content is not a recognized image
+	MIMEType string
+
+	// Ext is the usual extension for the MIMEType, without the dot
+	Ext string
+
+	// Width and Height are in pixels, and zero when they could not be
+	// read
+	Width  int
+	Height int
+}
+
+// IsImage is true when the content is a recognized image
+func (contentType *ContentType) IsImage() bool {
+	return contentType.MIMEType != ""
+}
+
+// NotAnImageError is returned when Options.RequireImage is set and the
+// content is not a recognized image
+type NotAnImageError struct {
+	Path string
+}
+
+func (err *NotAnImageError) Error() string {
+	return fmt.Sprintf("The content for %v is not a PNG, JPEG, GIF, WebP, BMP or SVG image", err.Path)
+}
+
+// ContentTooLargeError is returned when the content is larger than
+// Options.MaxSize
+type ContentTooLargeError struct {
+	Path    string
+	MaxSize int64
+}
+
+func (err *ContentTooLargeError) Error() string {
+	return fmt.Sprintf("The content for %v is larger than the maximum of %v bytes", err.Path, err.MaxSize)
+}
+
+// DimensionsTooLargeError is returned when the image is wider than
+// Options.MaxWidth or taller than Options.MaxHeight
+type DimensionsTooLargeError struct {
+	Path      string
+	Width     int
+	Height    int
+	MaxWidth  int
+	MaxHeight int
+}
+
+func (err *DimensionsTooLargeError) Error() string {
+	return fmt.Sprintf("The image for %v is %vx%v, larger than the maximum of %vx%v", err.Path, err.Width, err.Height, dimension(err.MaxWidth), dimension(err.MaxHeight))
+}
+
+// dimension formats a maximum dimension, where zero means no maximum
+func dimension(max int) string {
+	if max <= 0 {
+		return "any"
+	}
+	return strconv.Itoa(max)
+}
+
+// imageExtensions are the extensions of every recognized image type,
+// the usual one first
+var imageExtensions = map[string][]string{
+	"image/bmp":     {"bmp"},
+	"image/gif":     {"gif"},
+	"image/jpeg":    {"jpg", "jpeg", "jpe"},
+	"image/png":     {"png"},
+	"image/svg+xml": {"svg"},
+	"image/webp":    {"webp"},
+}
+
+// SniffContentType tells the image type and dimensions from the start
+// of the content
+func SniffContentType(head []byte) *ContentType {
+	mimeType := sniffMIMEType(head)
+	if mimeType == "" {
+		return &ContentType{}
+	}
+
+	contentType := &ContentType{MIMEType: mimeType, Ext: imageExtensions[mimeType][0]}
+	switch mimeType {
+	case "image/bmp":
+		contentType.Width, contentType.Height = bmpDimensions(head)
+	case "image/webp":
+		contentType.Width, contentType.Height = webpDimensions(head)
+	case "image/svg+xml":
+		contentType.Width, contentType.Height = svgDimensions(head)
+	default:
+		config, _, err := image.DecodeConfig(bytes.NewReader(head))
+		if err == nil {
+			contentType.Width, contentType.Height = config.Width, config.Height
+		}
+	}
+	return contentType
+}
+
+func sniffMIMEType(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "image/gif"
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return "image/webp"
+	case isBMP(head):
+		return "image/bmp"
+	case isSVG(head):
+		return "image/svg+xml"
+	}
+	return ""
+}
+
+// isSVG is true when the first element of the XML document is svg
+func isSVG(head []byte) bool {
+	_, ok := svgElement(head)
+	return ok
+}
+
+// svgElement finds the root element of an SVG document
+func svgElement(head []byte) (xml.StartElement, bool) {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return xml.StartElement{}, false
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(trimmed))
+	decoder.Strict = false
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return xml.StartElement{}, false
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			return token, token.Name.Local == "svg"
+		case xml.CharData:
+			if len(bytes.TrimSpace(token)) > 0 {
+				return xml.StartElement{}, false
+			}
+		}
+	}
+}
+
+// bmpHeaderSizes are the sizes of the DIB headers that may follow the
+// BMP file header, from BITMAPCOREHEADER to BITMAPV5HEADER
+var bmpHeaderSizes = map[uint32]bool{12: true, 40: true, 52: true, 56: true, 108: true, 124: true}
+
+// maxBMPDimension is larger than any real BMP is wide or high, so that
+// text starting with BM is not taken for one
+const maxBMPDimension = 1 << 16
+
+// isBMP is true when the BM signature is followed by a known DIB header
+// with a plausible width and height
+func isBMP(head []byte) bool {
+	if len(head) < 26 || !bytes.HasPrefix(head, []byte("BM")) {
+		return false
+	}
+	if !bmpHeaderSizes[binary.LittleEndian.Uint32(head[14:18])] {
+		return false
+	}
+
+	width, height := bmpDimensions(head)
+	return width > 0 && width <= maxBMPDimension && height > 0 && height <= maxBMPDimension
+}
+
+// bmpDimensions reads the DIB header. BITMAPCOREHEADER has 16 bit
+// dimensions, and the others 32 bit, where a negative height means the
+// rows are stored top down
+func bmpDimensions(head []byte) (int, int) {
+	if binary.LittleEndian.Uint32(head[14:18]) == 12 {
+		return int(binary.LittleEndian.Uint16(head[18:20])), int(binary.LittleEndian.Uint16(head[20:22]))
+	}
+
+	width := int(int32(binary.LittleEndian.Uint32(head[18:22])))
+	height := int(int32(binary.LittleEndian.Uint32(head[22:26])))
+	if height < 0 {
+		height = -height
+	}
+	return width, height
+}
+
+// webpDimensions reads the header of the lossy (VP8), lossless (VP8L)
+// or extended (VP8X) format
+func webpDimensions(head []byte) (int, int) {
+	if len(head) < 30 {
+		return 0, 0
+	}
+
+	switch string(head[12:16]) {
+	case "VP8 ":
+		width := int(binary.LittleEndian.Uint16(head[26:28]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(head[28:30]) & 0x3fff)
+		return width, height
+	case "VP8L":
+		bits := binary.LittleEndian.Uint32(head[21:25])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1
+	case "VP8X":
+		width := int(head[24]) | int(head[25])<<8 | int(head[26])<<16
+		height := int(head[27]) | int(head[28])<<8 | int(head[29])<<16
+		return width + 1, height + 1
+	}
+	return 0, 0
+}
+
+// svgDimensions reads the width and height attributes of the svg
+// element, falling back to its viewBox
+func svgDimensions(head []byte) (int, int) {
+	element, _ := svgElement(head)
+
+	var width, height int
+	var viewBox []string
+	for _, attr := range element.Attr {
+		switch attr.Name.Local {
+		case "width":
+			width = svgLength(attr.Value)
+		case "height":
+			height = svgLength(attr.Value)
+		case "viewBox":
+			viewBox = strings.Fields(strings.Replace(attr.Value, ",", " ", -1))
+		}
+	}
+
+	if (width == 0 || height == 0) && len(viewBox) == 4 {
+		width, height = svgLength(viewBox[2]), svgLength(viewBox[3])
+	}
+	return width, height
+}
+
+// svgLength reads a length in pixels, returning zero for lengths in
+// any other unit
+func svgLength(value string) int {
+	length, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "px"), 64)
+	if err != nil || length < 0 {
+		return 0
+	}
+	return int(length + 0.5)
+}
+
+// FixExtension gives filepath the usual extension of the image type,
+// replacing the extension of another image type or adding one when it
+// has none. Paths of content that is not a recognized image, or that
+// have an extension of its type or one that is not of an image, are
+// returned unchanged
+func FixExtension(filepath string, contentType *ContentType) string {
+	if !contentType.IsImage() {
+		return filepath
+	}
+
+	ext := path.Ext(filepath)
+	name := strings.ToLower(strings.TrimPrefix(ext, "."))
+	for _, imageExt := range imageExtensions[contentType.MIMEType] {
+		if name == imageExt {
+			return filepath
+		}
+	}
+
+	if name == "" {
+		return filepath + "." + contentType.Ext
+	}
+	if isImageExtension(name) {
+		return strings.TrimSuffix(filepath, ext) + "." + contentType.Ext
+	}
+	return filepath
+}
+
+func isImageExtension(name string) bool {
+	for _, exts := range imageExtensions {
+		for _, ext := range exts {
+			if name == ext {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxSizeReader fails with a ContentTooLargeError once more than
+// maxSize bytes have been read, so a too large upload stops before it
+// is committed
+type maxSizeReader struct {
+	reader io.Reader
+	path   string
+	read   int64
+	max    int64
+}
+
+func (reader *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := reader.reader.Read(p)
+	reader.read += int64(n)
+	if reader.read > reader.max {
+		return n, &ContentTooLargeError{Path: reader.path, MaxSize: reader.max}
+	}
+	return n, err
+}
@@ -0,0 +1,201 @@
+package uploader_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"io/ioutil"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func bmpImage(width, height int32) []byte {
+	header := make([]byte, 54)
+	copy(header, "BM")
+	binary.LittleEndian.PutUint32(header[14:], 40)
+	binary.LittleEndian.PutUint32(header[18:], uint32(width))
+	binary.LittleEndian.PutUint32(header[22:], uint32(height))
+	return header
+}
+
+func webpImage(width, height int) []byte {
+	header := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00")
+	for _, size := range []int{width - 1, height - 1} {
+		header = append(header, byte(size), byte(size>>8), byte(size>>16))
+	}
+	return header
+}
+
+var _ = Describe("Content types", func() {
+	Describe("uploader.SniffContentType(head)", func() {
+		It("Should recognize a PNG", func() {
+			data, _ := ioutil.ReadAll(sampleImage())
+			Expect(*uploader.SniffContentType(data)).To(Equal(uploader.ContentType{MIMEType: "image/png", Ext: "png", Width: 1, Height: 1}))
+		})
+
+		It("Should recognize a JPEG", func() {
+			var data bytes.Buffer
+			Expect(jpeg.Encode(&data, image.NewGray(image.Rect(0, 0, 40, 30)), nil)).To(Succeed())
+			Expect(*uploader.SniffContentType(data.Bytes())).To(Equal(uploader.ContentType{MIMEType: "image/jpeg", Ext: "jpg", Width: 40, Height: 30}))
+		})
+
+		It("Should recognize a GIF", func() {
+			var data bytes.Buffer
+			Expect(gif.Encode(&data, image.NewGray(image.Rect(0, 0, 3, 2)), nil)).To(Succeed())
+			Expect(*uploader.SniffContentType(data.Bytes())).To(Equal(uploader.ContentType{MIMEType: "image/gif", Ext: "gif", Width: 3, Height: 2}))
+		})
+
+		It("Should recognize a WebP", func() {
+			Expect(*uploader.SniffContentType(webpImage(1920, 1080))).To(Equal(uploader.ContentType{MIMEType: "image/webp", Ext: "webp", Width: 1920, Height: 1080}))
+		})
+
+		It("Should recognize a top down BMP", func() {
+			Expect(*uploader.SniffContentType(bmpImage(640, -480))).To(Equal(uploader.ContentType{MIMEType: "image/bmp", Ext: "bmp", Width: 640, Height: 480}))
+		})
+
+		It("Should recognize a BMP with a BITMAPCOREHEADER", func() {
+			data := make([]byte, 26)
+			copy(data, "BM")
+			binary.LittleEndian.PutUint32(data[14:], 12)
+			binary.LittleEndian.PutUint16(data[18:], 320)
+			binary.LittleEndian.PutUint16(data[20:], 200)
+			Expect(*uploader.SniffContentType(data)).To(Equal(uploader.ContentType{MIMEType: "image/bmp", Ext: "bmp", Width: 320, Height: 200}))
+		})
+
+		It("Should not take text starting with BM for a BMP", func() {
+			data := []byte("BMW quarterly report: deliveries rose in every region")
+			Expect(*uploader.SniffContentType(data)).To(Equal(uploader.ContentType{}))
+		})
+
+		It("Should not take a BMP header without a width for a BMP", func() {
+			Expect(*uploader.SniffContentType(bmpImage(0, 480))).To(Equal(uploader.ContentType{}))
+		})
+
+		It("Should recognize an SVG with an XML declaration", func() {
+			data := []byte(`<?xml version="1.0"?>
+<!-- chart -->
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 300 150"></svg>`)
+			Expect(*uploader.SniffContentType(data)).To(Equal(uploader.ContentType{MIMEType: "image/svg+xml", Ext: "svg", Width: 300, Height: 150}))
+		})
+
+		It("Should prefer the width and height of an SVG to its viewBox", func() {
+			data := []byte(`<svg width="64px" height="32" viewBox="0 0 300 150"/>`)
+			Expect(*uploader.SniffContentType(data)).To(Equal(uploader.ContentType{MIMEType: "image/svg+xml", Ext: "svg", Width: 64, Height: 32}))
+		})
+
+		It("Should not recognize other content", func() {
+			Expect(uploader.SniffContentType([]byte("<html><body>Build log</body></html>")).IsImage()).To(BeFalse())
+			Expect(uploader.SniffContentType([]byte("plain text")).IsImage()).To(BeFalse())
+		})
+	})
+
+	Describe("uploader.FixExtension(filepath, contentType)", func() {
+		png := &uploader.ContentType{MIMEType: "image/png", Ext: "png"}
+		jpg := &uploader.ContentType{MIMEType: "image/jpeg", Ext: "jpg"}
+
+		It("Should add a missing extension", func() {
+			Expect(uploader.FixExtension("/failures/latest", png)).To(Equal("/failures/latest.png"))
+		})
+
+		It("Should replace the extension of another image type", func() {
+			Expect(uploader.FixExtension("/failures/latest.JPG", png)).To(Equal("/failures/latest.png"))
+		})
+
+		It("Should keep any extension of the image type", func() {
+			Expect(uploader.FixExtension("/failures/latest.jpeg", jpg)).To(Equal("/failures/latest.jpeg"))
+		})
+
+		It("Should leave an extension that is not of an image", func() {
+			Expect(uploader.FixExtension("/failures/report.txt", png)).To(Equal("/failures/report.txt"))
+			Expect(uploader.FixExtension("/failures/build.1.2", png)).To(Equal("/failures/build.1.2"))
+		})
+
+		It("Should leave the path of content that is not an image", func() {
+			Expect(uploader.FixExtension("/failures/latest.png", &uploader.ContentType{})).To(Equal("/failures/latest.png"))
+		})
+	})
+
+	Describe("sut.UploadWithResult(filepath, content) with content options", func() {
+		var fakeClient *FakeClient
+		var options uploader.Options
+		var result *uploader.Result
+		var err error
+
+		BeforeEach(func() {
+			fakeClient = NewFakeClient()
+			fakeClient.UploadSpy.ReturnsFileMetadata = &files.FileMetadata{PathLower: "/failures/latest.png"}
+			fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsSharedLinkMetadata = &sharing.SharedLinkMetadata{
+				File: &sharing.FileLinkMetadata{Url: "https://dropbox.biz/failures/latest.png"},
+			}
+			options = uploader.Options{}
+		})
+
+		upload := func(filepath string, content []byte) {
+			sut := uploader.NewWithClientAndOptions(fakeClient, options)
+			result, err = sut.UploadWithResult(filepath, bytes.NewReader(content))
+		}
+
+		It("Should return the type and dimensions of the image", func() {
+			upload("/failures/latest.png", webpImage(800, 600))
+			Expect(err).To(BeNil())
+			Expect(result.MIMEType).To(Equal("image/webp"))
+			Expect(result.Width).To(Equal(800))
+			Expect(result.Height).To(Equal(600))
+		})
+
+		It("Should upload the whole content", func() {
+			content := append(bmpImage(2, 2), make([]byte, 100*1024)...)
+			upload("/failures/latest.bmp", content)
+			Expect(err).To(BeNil())
+			Expect(fakeClient.UploadSpy.LastCalledWithContent).To(Equal(bytes.NewReader(content)))
+		})
+
+		It("Should fix the extension when asked to", func() {
+			options.FixExtension = true
+			upload("/failures/latest.jpg", bmpImage(2, 2))
+			Expect(fakeClient.UploadSpy.LastCalledWithCommitInfo.Path).To(Equal("/failures/latest.bmp"))
+		})
+
+		It("Should reject content that is not an image when asked to", func() {
+			options.RequireImage = true
+			upload("/failures/latest.png", []byte("plain text"))
+
+			var notAnImageError *uploader.NotAnImageError
+			Expect(errors.As(err, &notAnImageError)).To(BeTrue())
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(0))
+		})
+
+		It("Should reject content larger than the maximum size", func() {
+			options.MaxSize = 1024
+			upload("/failures/latest.bmp", append(bmpImage(2, 2), make([]byte, 1024)...))
+
+			var contentTooLargeError *uploader.ContentTooLargeError
+			Expect(errors.As(err, &contentTooLargeError)).To(BeTrue())
+			Expect(err.Error()).To(Equal("The content for /failures/latest.bmp is larger than the maximum of 1024 bytes"))
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(0))
+		})
+
+		It("Should reject images larger than the maximum dimensions", func() {
+			options.MaxHeight = 1000
+			upload("/failures/latest.bmp", bmpImage(640, 1200))
+
+			var dimensionsTooLargeError *uploader.DimensionsTooLargeError
+			Expect(errors.As(err, &dimensionsTooLargeError)).To(BeTrue())
+			Expect(err.Error()).To(Equal("The image for /failures/latest.bmp is 640x1200, larger than the maximum of anyx1000"))
+		})
+
+		It("Should upload images within the maximum dimensions", func() {
+			options.MaxWidth, options.MaxHeight = 640, 1200
+			upload("/failures/latest.bmp", bmpImage(640, 1200))
+			Expect(err).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,27 @@
+package uploader
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// DirectURL turns a dropbox shared link, which opens a preview page,
+// into a link to the file's content. Mode "raw" serves the content to be
+// shown in place, and "dl" serves it as a download
+func DirectURL(sharedURL, mode string) (string, error) {
+	if mode != "raw" && mode != "dl" {
+		return "", fmt.Errorf("Unknown direct link mode: %v, expected raw or dl", mode)
+	}
+
+	parsedURL, err := url.Parse(sharedURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsedURL.Query()
+	query.Del("dl")
+	query.Del("raw")
+	query.Set(mode, "1")
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String(), nil
+}
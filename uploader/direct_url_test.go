@@ -0,0 +1,33 @@
+package uploader_test
+
+import (
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DirectURL", func() {
+	It("Should replace dl=0 with raw=1", func() {
+		directURL, err := uploader.DirectURL("https://www.dropbox.com/s/abc123/example.png?dl=0", "raw")
+		Expect(err).To(BeNil())
+		Expect(directURL).To(Equal("https://www.dropbox.com/s/abc123/example.png?raw=1"))
+	})
+
+	It("Should replace dl=0 with dl=1", func() {
+		directURL, err := uploader.DirectURL("https://www.dropbox.com/s/abc123/example.png?dl=0", "dl")
+		Expect(err).To(BeNil())
+		Expect(directURL).To(Equal("https://www.dropbox.com/s/abc123/example.png?dl=1"))
+	})
+
+	It("Should keep the other query parameters", func() {
+		directURL, err := uploader.DirectURL("https://www.dropbox.com/scl/fi/abc123/example.png?rlkey=xyz&dl=0", "raw")
+		Expect(err).To(BeNil())
+		Expect(directURL).To(Equal("https://www.dropbox.com/scl/fi/abc123/example.png?raw=1&rlkey=xyz"))
+	})
+
+	It("Should reject an unknown mode", func() {
+		_, err := uploader.DirectURL("https://www.dropbox.com/s/abc123/example.png?dl=0", "inline")
+		Expect(err).NotTo(BeNil())
+	})
+})
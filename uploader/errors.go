@@ -0,0 +1,232 @@
+package uploader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/apierror"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
+)
+
+// PathConflictError is returned when something already exists at the
+// path and the write mode does not allow replacing it
+type PathConflictError struct {
+	Path string
+	Err  error
+}
+
+func (err *PathConflictError) Error() string {
+	return fmt.Sprintf("Something already exists at %v: %v", err.Path, err.Err)
+}
+
+func (err *PathConflictError) Unwrap() error {
+	return err.Err
+}
+
+// InsufficientSpaceError is returned when the dropbox account is full
+type InsufficientSpaceError struct {
+	Path string
+	Err  error
+}
+
+func (err *InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("Not enough space in dropbox to write %v: %v", err.Path, err.Err)
+}
+
+func (err *InsufficientSpaceError) Unwrap() error {
+	return err.Err
+}
+
+// MalformedPathError is returned when dropbox does not accept the path
+type MalformedPathError struct {
+	Path string
+	Err  error
+}
+
+func (err *MalformedPathError) Error() string {
+	return fmt.Sprintf("Dropbox rejected the path %v: %v", err.Path, err.Err)
+}
+
+func (err *MalformedPathError) Unwrap() error {
+	return err.Err
+}
+
+// RateLimitedError is returned when dropbox is throttling requests.
+// RetryAfter is zero when dropbox did not say how long to wait, and is
+// always zero for uploads: the dropbox client drops the response
+// headers, so only files/get_metadata and the shared link requests, see
+// FileInfoClient and NewLinkSettingsClient, read Retry-After
+type RateLimitedError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (err *RateLimitedError) Error() string {
+	if err.RetryAfter == 0 {
+		return fmt.Sprintf("Rate limited by dropbox: %v", err.Err)
+	}
+	return fmt.Sprintf("Rate limited by dropbox, retry after %v: %v", err.RetryAfter, err.Err)
+}
+
+func (err *RateLimitedError) Unwrap() error {
+	return err.Err
+}
+
+// Retryable is always true, dropbox accepts the request once the rate
+// limit has passed
+func (err *RateLimitedError) Retryable() bool {
+	return true
+}
+
+// RetryAfterDuration returns how long dropbox asked to wait, if it said
+func (err *RateLimitedError) RetryAfterDuration() time.Duration {
+	return err.RetryAfter
+}
+
+// ServerError is returned when dropbox fails to process a request on its
+// side. The dropbox client only fails to decode an error response as JSON
+// when dropbox answered with a plain server error page
+type ServerError struct {
+	Err error
+}
+
+func (err *ServerError) Error() string {
+	return fmt.Sprintf("Dropbox server error: %v", err.Err)
+}
+
+func (err *ServerError) Unwrap() error {
+	return err.Err
+}
+
+// Retryable is always true, server errors are usually temporary
+func (err *ServerError) Retryable() bool {
+	return true
+}
+
+// AuthInvalidError is returned when the access token is invalid or expired
+type AuthInvalidError struct {
+	Err error
+}
+
+func (err *AuthInvalidError) Error() string {
+	return fmt.Sprintf("Dropbox rejected the access token: %v", err.Err)
+}
+
+func (err *AuthInvalidError) Unwrap() error {
+	return err.Err
+}
+
+// LinkAlreadyExistsError is returned when a shared link to the path
+// already exists
+type LinkAlreadyExistsError struct {
+	Path string
+	Err  error
+}
+
+func (err *LinkAlreadyExistsError) Error() string {
+	return fmt.Sprintf("A shared link to %v already exists: %v", err.Path, err.Err)
+}
+
+func (err *LinkAlreadyExistsError) Unwrap() error {
+	return err.Err
+}
+
+// decodeError converts the errors returned by the dropbox client for a
+// request about path into the error types above. Errors that do not
+// match any of them are returned unchanged
+func decodeError(err error, path string) error {
+	if err == nil {
+		return nil
+	}
+
+	if decoded := decodeEndpointError(err, path); decoded != nil {
+		return decoded
+	}
+
+	if _, ok := err.(*json.SyntaxError); ok {
+		return &ServerError{Err: err}
+	}
+
+	apiError, ok := err.(apierror.ApiError)
+	if !ok {
+		return err
+	}
+
+	summary := apiError.ErrorSummary
+	switch {
+	case hasTag(summary, "invalid_access_token"), hasTag(summary, "expired_access_token"):
+		return &AuthInvalidError{Err: err}
+	case hasTag(summary, "too_many_requests"), hasTag(summary, "too_many_write_operations"):
+		return &RateLimitedError{Err: err}
+	}
+
+	return err
+}
+
+// isRetryable reports whether a request that failed with err, as returned
+// by the dropbox client, is worth retrying
+func isRetryable(err error) bool {
+	return retry.IsRetryable(decodeError(err, ""))
+}
+
+// decodeEndpointError decodes the route specific part of the error, or
+// returns nil when there is nothing it recognizes
+func decodeEndpointError(err error, path string) error {
+	switch endpointError := endpointError(err).(type) {
+	case *files.UploadError:
+		if endpointError != nil && endpointError.Path != nil {
+			return decodeWriteError(err, path, endpointError.Path.Reason)
+		}
+	case *files.UploadSessionFinishError:
+		if endpointError != nil {
+			return decodeWriteError(err, path, endpointError.Path)
+		}
+	case *sharing.CreateSharedLinkWithSettingsError:
+		if endpointError == nil {
+			return nil
+		}
+		if endpointError.Tag == "shared_link_already_exists" {
+			return &LinkAlreadyExistsError{Path: path, Err: err}
+		}
+		return decodeLookupError(err, path, endpointError.Path)
+	case *sharing.ListSharedLinksError:
+		if endpointError != nil {
+			return decodeLookupError(err, path, endpointError.Path)
+		}
+	}
+
+	return nil
+}
+
+func decodeWriteError(err error, path string, writeError *files.WriteError) error {
+	if writeError == nil {
+		return nil
+	}
+
+	switch writeError.Tag {
+	case "conflict":
+		return &PathConflictError{Path: path, Err: err}
+	case "insufficient_space":
+		return &InsufficientSpaceError{Path: path, Err: err}
+	case "malformed_path":
+		return &MalformedPathError{Path: path, Err: err}
+	}
+	return nil
+}
+
+func decodeLookupError(err error, path string, lookupError *files.LookupError) error {
+	if lookupError == nil || lookupError.Tag != "malformed_path" {
+		return nil
+	}
+	return &MalformedPathError{Path: path, Err: err}
+}
+
+// hasTag reports whether an error summary such as
+// "too_many_requests/..." starts with the given tag
+func hasTag(summary, tag string) bool {
+	return summary == tag || strings.HasPrefix(summary, tag+"/")
+}
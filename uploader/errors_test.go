@@ -0,0 +1,122 @@
+package uploader_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/apierror"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func uploadWriteError(tag string) error {
+	writeError := &files.WriteError{Tag: tag}
+	uploadError := &files.UploadError{Tag: "path", Path: files.NewUploadWriteFailed(writeError, "")}
+	return FakeAPIError{ApiError: apierror.ApiError{ErrorSummary: "path/" + tag + "/.."}, EndpointError: uploadError}
+}
+
+var _ = Describe("Dropbox errors", func() {
+	var sut uploader.Uploader
+	var fakeClient *FakeClient
+	var err error
+
+	BeforeEach(func() {
+		fakeClient = NewFakeClient()
+		fakeClient.UploadSpy.ReturnsFileMetadata = &files.FileMetadata{PathLower: "/failures/example-2016-01-02.png"}
+		sut = uploader.NewWithClient(fakeClient)
+	})
+
+	Describe("when the upload conflicts with an existing file", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSpy.ReturnsError = uploadWriteError("conflict")
+			_, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have a PathConflictError", func() {
+			var pathConflictError *uploader.PathConflictError
+			Expect(errors.As(err, &pathConflictError)).To(BeTrue())
+			Expect(pathConflictError.Path).To(Equal("/failures/example-2016-01-02.png"))
+		})
+
+		It("Should wrap the original error", func() {
+			Expect(errors.Is(err, fakeClient.UploadSpy.ReturnsError)).To(BeTrue())
+		})
+	})
+
+	Describe("when the dropbox account is full", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSpy.ReturnsError = uploadWriteError("insufficient_space")
+			_, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have an InsufficientSpaceError", func() {
+			var insufficientSpaceError *uploader.InsufficientSpaceError
+			Expect(errors.As(err, &insufficientSpaceError)).To(BeTrue())
+		})
+	})
+
+	Describe("when the path is malformed", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSpy.ReturnsError = uploadWriteError("malformed_path")
+			_, err = sut.Upload("failures", sampleImage())
+		})
+
+		It("Should have a MalformedPathError", func() {
+			var malformedPathError *uploader.MalformedPathError
+			Expect(errors.As(err, &malformedPathError)).To(BeTrue())
+			Expect(malformedPathError.Path).To(Equal("failures"))
+		})
+	})
+
+	Describe("when creating the shared link hits a malformed path", func() {
+		BeforeEach(func() {
+			endpointError := &sharing.CreateSharedLinkWithSettingsError{Tag: "path", Path: &files.LookupError{Tag: "malformed_path"}}
+			fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsError = FakeAPIError{EndpointError: endpointError}
+			_, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have a MalformedPathError", func() {
+			var malformedPathError *uploader.MalformedPathError
+			Expect(errors.As(err, &malformedPathError)).To(BeTrue())
+		})
+	})
+
+	Describe("when dropbox is rate limiting", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSpy.ReturnsError = apierror.ApiError{ErrorSummary: "too_many_write_operations/.."}
+			_, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have a RateLimitedError", func() {
+			var rateLimitedError *uploader.RateLimitedError
+			Expect(errors.As(err, &rateLimitedError)).To(BeTrue())
+		})
+	})
+
+	Describe("when the access token is invalid", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSpy.ReturnsError = apierror.ApiError{ErrorSummary: "invalid_access_token/..."}
+			_, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have an AuthInvalidError", func() {
+			var authInvalidError *uploader.AuthInvalidError
+			Expect(errors.As(err, &authInvalidError)).To(BeTrue())
+		})
+	})
+
+	Describe("when the error is not recognized", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSpy.ReturnsError = fmt.Errorf("Error uploading.")
+			_, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should return the error unchanged", func() {
+			Expect(err).To(Equal(fakeClient.UploadSpy.ReturnsError))
+		})
+	})
+})
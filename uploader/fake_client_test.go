@@ -1,9 +1,26 @@
 package uploader_test
 
 import "io"
+import "io/ioutil"
+import "github.com/dropbox/dropbox-sdk-go-unofficial/apierror"
 import "github.com/dropbox/dropbox-sdk-go-unofficial/files"
 import "github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
 
+// FakeAPIError mimics the errors the dropbox client returns, which carry
+// the decoded route specific error in an EndpointError field
+type FakeAPIError struct {
+	apierror.ApiError
+	EndpointError interface{}
+}
+
+// NewSharedLinkAlreadyExistsError returns the error dropbox responds with
+// when creating a shared link to a path that already has one
+func NewSharedLinkAlreadyExistsError() error {
+	endpointError := &sharing.CreateSharedLinkWithSettingsError{Tag: "shared_link_already_exists"}
+	apiError := apierror.ApiError{ErrorSummary: "shared_link_already_exists/.."}
+	return FakeAPIError{ApiError: apiError, EndpointError: endpointError}
+}
+
 type FakeClient struct {
 	CreateSharedLinkWithSettingsSpy struct {
 		CallCount                 int
@@ -18,13 +35,43 @@ type FakeClient struct {
 		ReturnsListSharedLinksResult *sharing.ListSharedLinksResult
 	}
 
+	ModifySharedLinkSettingsSpy struct {
+		CallCount                 int
+		LastCalledWith            *sharing.ModifySharedLinkSettingsArgs
+		ReturnsError              error
+		ReturnsSharedLinkMetadata *sharing.SharedLinkMetadata
+	}
+
 	UploadSpy struct {
 		CallCount                int
 		LastCalledWithCommitInfo *files.CommitInfo
 		LastCalledWithContent    io.Reader
 		ReturnsError             error
+		ReturnsErrors            []error
 		ReturnsFileMetadata      *files.FileMetadata
 	}
+
+	UploadSessionAppendSpy struct {
+		CallCount          int
+		CalledWithCursors  []*files.UploadSessionCursor
+		CalledWithContents [][]byte
+		ReturnsErrors      []error
+	}
+
+	UploadSessionFinishSpy struct {
+		CallCount           int
+		CalledWith          []*files.UploadSessionFinishArg
+		CalledWithContents  [][]byte
+		ReturnsErrors       []error
+		ReturnsFileMetadata *files.FileMetadata
+	}
+
+	UploadSessionStartSpy struct {
+		CallCount                       int
+		LastCalledWithContent           []byte
+		ReturnsError                    error
+		ReturnsUploadSessionStartResult *files.UploadSessionStartResult
+	}
 }
 
 func NewFakeClient() *FakeClient {
@@ -47,11 +94,76 @@ func (client *FakeClient) ListSharedLinks(arg *sharing.ListSharedLinksArg) (res
 	return spy.ReturnsListSharedLinksResult, nil
 }
 
+func (client *FakeClient) ModifySharedLinkSettings(arg *sharing.ModifySharedLinkSettingsArgs) (res *sharing.SharedLinkMetadata, err error) {
+	spy := &client.ModifySharedLinkSettingsSpy
+
+	spy.CallCount++
+	spy.LastCalledWith = arg
+	return spy.ReturnsSharedLinkMetadata, spy.ReturnsError
+}
+
 func (client *FakeClient) Upload(commitInfo *files.CommitInfo, content io.Reader) (res *files.FileMetadata, err error) {
 	spy := &client.UploadSpy
 
 	spy.CallCount++
 	spy.LastCalledWithCommitInfo = commitInfo
 	spy.LastCalledWithContent = content
+	if err := nthError(spy.ReturnsErrors, spy.CallCount); err != nil {
+		return nil, err
+	}
 	return spy.ReturnsFileMetadata, spy.ReturnsError
 }
+
+func (client *FakeClient) UploadSessionAppend(cursor *files.UploadSessionCursor, content io.Reader) (err error) {
+	spy := &client.UploadSessionAppendSpy
+
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+
+	spy.CallCount++
+	spy.CalledWithCursors = append(spy.CalledWithCursors, cursor)
+	spy.CalledWithContents = append(spy.CalledWithContents, data)
+	return nthError(spy.ReturnsErrors, spy.CallCount)
+}
+
+func (client *FakeClient) UploadSessionFinish(arg *files.UploadSessionFinishArg, content io.Reader) (res *files.FileMetadata, err error) {
+	spy := &client.UploadSessionFinishSpy
+
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+
+	spy.CallCount++
+	spy.CalledWith = append(spy.CalledWith, arg)
+	spy.CalledWithContents = append(spy.CalledWithContents, data)
+	err = nthError(spy.ReturnsErrors, spy.CallCount)
+	if err != nil {
+		return nil, err
+	}
+	return spy.ReturnsFileMetadata, nil
+}
+
+func (client *FakeClient) UploadSessionStart(content io.Reader) (res *files.UploadSessionStartResult, err error) {
+	spy := &client.UploadSessionStartSpy
+
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+
+	spy.CallCount++
+	spy.LastCalledWithContent = data
+	return spy.ReturnsUploadSessionStartResult, spy.ReturnsError
+}
+
+// nthError returns the error queued for the nth call, or nil once the
+// queue has run out
+func nthError(errs []error, n int) error {
+	if n > len(errs) {
+		return nil
+	}
+	return errs[n-1]
+}
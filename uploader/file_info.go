@@ -0,0 +1,133 @@
+package uploader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGetMetadataURL is the files/get_metadata endpoint of the
+	// dropbox API
+	DefaultGetMetadataURL = "https://api.dropboxapi.com/2/files/get_metadata"
+
+	// DefaultFileInfoTimeout limits each files/get_metadata request when
+	// no http.Client is given
+	DefaultFileInfoTimeout = 30 * time.Second
+)
+
+// FileInfo is the metadata of a file in dropbox, including the
+// content_hash the dropbox client does not decode
+type FileInfo struct {
+	PathLower      string    `json:"path_lower"`
+	PathDisplay    string    `json:"path_display"`
+	Rev            string    `json:"rev"`
+	Size           int64     `json:"size"`
+	ServerModified time.Time `json:"server_modified"`
+	ContentHash    string    `json:"content_hash"`
+}
+
+// FileInfoClient gets the FileInfo of the file at a path. A Client that
+// also implements it is used to skip unchanged uploads and verify the
+// content_hash of each upload
+type FileInfoClient interface {
+	// GetFileInfo returns nil, without an error, when there is no file
+	// at the path
+	GetFileInfo(path string) (*FileInfo, error)
+}
+
+// ContentHashMismatchError is returned when the content_hash dropbox
+// computed for an upload differs from the one computed from the content
+// that was sent
+type ContentHashMismatchError struct {
+	Path   string
+	Local  string
+	Server string
+}
+
+func (err *ContentHashMismatchError) Error() string {
+	return fmt.Sprintf("Dropbox stored different content at %v: content_hash %v, expected %v", err.Path, err.Server, err.Local)
+}
+
+// clientWithFileInfo adds GetFileInfo to a dropbox client
+type clientWithFileInfo struct {
+	Client
+	FileInfoClient
+}
+
+type httpFileInfoClient struct {
+	accessToken    string
+	getMetadataURL string
+	httpClient     *http.Client
+}
+
+// NewFileInfoClient constructs a FileInfoClient calling files/get_metadata
+// at getMetadataURL, which defaults to DefaultGetMetadataURL
+func NewFileInfoClient(accessToken, getMetadataURL string, httpClient *http.Client) FileInfoClient {
+	if getMetadataURL == "" {
+		getMetadataURL = DefaultGetMetadataURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultFileInfoTimeout}
+	}
+	return &httpFileInfoClient{accessToken, getMetadataURL, httpClient}
+}
+
+type getMetadataError struct {
+	ErrorSummary string `json:"error_summary"`
+}
+
+func (client *httpFileInfoClient) GetFileInfo(path string) (*FileInfo, error) {
+	body, err := json.Marshal(map[string]string{"path": path})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", client.getMetadataURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+client.accessToken)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case response.StatusCode == http.StatusOK:
+		var fileInfo FileInfo
+		err = json.Unmarshal(responseBody, &fileInfo)
+		if err != nil {
+			return nil, &ServerError{Err: err}
+		}
+		return &fileInfo, nil
+	case response.StatusCode == http.StatusConflict:
+		var apiError getMetadataError
+		json.Unmarshal(responseBody, &apiError)
+		if strings.HasPrefix(apiError.ErrorSummary, "path/not_found") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Dropbox could not get the metadata of %v: %v", path, apiError.ErrorSummary)
+	case response.StatusCode == http.StatusUnauthorized:
+		return nil, &AuthInvalidError{Err: fmt.Errorf("%v", strings.TrimSpace(string(responseBody)))}
+	case response.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(response.Header.Get("Retry-After"))
+		return nil, &RateLimitedError{RetryAfter: time.Duration(seconds) * time.Second, Err: fmt.Errorf("%v", strings.TrimSpace(string(responseBody)))}
+	case response.StatusCode >= 500:
+		return nil, &ServerError{Err: fmt.Errorf("%v %v", response.StatusCode, strings.TrimSpace(string(responseBody)))}
+	}
+	return nil, fmt.Errorf("Unexpected status from dropbox files/get_metadata: %v, %v", response.StatusCode, strings.TrimSpace(string(responseBody)))
+}
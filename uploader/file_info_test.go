@@ -0,0 +1,238 @@
+package uploader_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// FakeClientWithFileInfo is a FakeClient that is also a FileInfoClient,
+// returning FileInfos in order, the last one over and over
+type FakeClientWithFileInfo struct {
+	*FakeClient
+	FileInfos        []*uploader.FileInfo
+	GetFileInfoCalls []string
+}
+
+func (client *FakeClientWithFileInfo) GetFileInfo(path string) (*uploader.FileInfo, error) {
+	client.GetFileInfoCalls = append(client.GetFileInfoCalls, path)
+	if len(client.FileInfos) == 0 {
+		return nil, nil
+	}
+	fileInfo := client.FileInfos[0]
+	if len(client.FileInfos) > 1 {
+		client.FileInfos = client.FileInfos[1:]
+	}
+	return fileInfo, nil
+}
+
+func sampleContentHash() string {
+	data, _ := ioutil.ReadAll(sampleImage())
+	contentHash := uploader.NewContentHash()
+	contentHash.Write(data)
+	return hex.EncodeToString(contentHash.Sum(nil))
+}
+
+var _ = Describe("FileInfo", func() {
+	Describe("sut.UploadWithResult(filepath, content) with a FileInfoClient", func() {
+		var fakeClient *FakeClientWithFileInfo
+		var options uploader.Options
+		var result *uploader.Result
+		var err error
+
+		BeforeEach(func() {
+			fakeClient = &FakeClientWithFileInfo{FakeClient: NewFakeClient()}
+			fakeClient.UploadSpy.ReturnsFileMetadata = &files.FileMetadata{PathLower: "/dashboards/latest.png", Rev: "b2"}
+			fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsSharedLinkMetadata = &sharing.SharedLinkMetadata{
+				File: &sharing.FileLinkMetadata{Url: "https://dropbox.biz/dashboards/latest.png"},
+			}
+			options = uploader.Options{SkipUnchanged: true, VerifyContentHash: true}
+		})
+
+		upload := func() {
+			sut := uploader.NewWithClientAndOptions(fakeClient, options)
+			result, err = sut.UploadWithResult("/dashboards/latest.png", sampleImage())
+		}
+
+		Describe("when the file already has the same content", func() {
+			BeforeEach(func() {
+				fakeClient.FileInfos = []*uploader.FileInfo{{
+					PathLower:      "/dashboards/latest.png",
+					PathDisplay:    "/Dashboards/latest.png",
+					Rev:            "a1",
+					Size:           67,
+					ServerModified: time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC),
+					ContentHash:    sampleContentHash(),
+				}}
+				upload()
+			})
+
+			It("Should not have uploaded", func() {
+				Expect(err).To(BeNil())
+				Expect(fakeClient.UploadSpy.CallCount).To(Equal(0))
+			})
+
+			It("Should describe the file as it already was", func() {
+				Expect(result.Unchanged).To(BeTrue())
+				Expect(result.URL).To(Equal("https://dropbox.biz/dashboards/latest.png"))
+				Expect(result.Path).To(Equal("/Dashboards/latest.png"))
+				Expect(result.Rev).To(Equal("a1"))
+				Expect(result.MIMEType).To(Equal("image/png"))
+			})
+		})
+
+		Describe("when the file has other content", func() {
+			BeforeEach(func() {
+				fakeClient.FileInfos = []*uploader.FileInfo{
+					{PathLower: "/dashboards/latest.png", Rev: "a1", ContentHash: "0123"},
+					{PathLower: "/dashboards/latest.png", Rev: "b2", ContentHash: sampleContentHash()},
+				}
+				upload()
+			})
+
+			It("Should have uploaded and verified the upload", func() {
+				Expect(err).To(BeNil())
+				Expect(fakeClient.UploadSpy.CallCount).To(Equal(1))
+				Expect(fakeClient.GetFileInfoCalls).To(Equal([]string{"/dashboards/latest.png", "/dashboards/latest.png"}))
+			})
+
+			It("Should describe the new revision", func() {
+				Expect(result.Unchanged).To(BeFalse())
+				Expect(result.Rev).To(Equal("b2"))
+				Expect(result.ContentHash).To(Equal(sampleContentHash()))
+			})
+		})
+
+		Describe("when dropbox stored other content than was sent", func() {
+			BeforeEach(func() {
+				options.SkipUnchanged = false
+				fakeClient.FileInfos = []*uploader.FileInfo{{PathLower: "/dashboards/latest.png", Rev: "b2", ContentHash: "0123"}}
+				upload()
+			})
+
+			It("Should have a ContentHashMismatchError", func() {
+				var mismatchError *uploader.ContentHashMismatchError
+				Expect(errors.As(err, &mismatchError)).To(BeTrue())
+				Expect(mismatchError.Server).To(Equal("0123"))
+				Expect(mismatchError.Local).To(Equal(sampleContentHash()))
+			})
+
+			It("Should only have checked after the upload", func() {
+				Expect(fakeClient.GetFileInfoCalls).To(HaveLen(1))
+			})
+		})
+
+		Describe("when the file was written again before it was verified", func() {
+			BeforeEach(func() {
+				options.SkipUnchanged = false
+				fakeClient.FileInfos = []*uploader.FileInfo{{PathLower: "/dashboards/latest.png", Rev: "c3", ContentHash: "0123"}}
+				upload()
+			})
+
+			It("Should not have an error", func() {
+				Expect(err).To(BeNil())
+			})
+
+			It("Should describe the revision it uploaded", func() {
+				Expect(result.Rev).To(Equal("b2"))
+				Expect(result.ContentHash).To(Equal(sampleContentHash()))
+			})
+		})
+
+		Describe("when the upload is not to be verified", func() {
+			BeforeEach(func() {
+				options = uploader.Options{}
+				fakeClient.FileInfos = []*uploader.FileInfo{{PathLower: "/dashboards/latest.png", Rev: "b2", ContentHash: "0123"}}
+				upload()
+			})
+
+			It("Should not have checked the content_hash", func() {
+				Expect(err).To(BeNil())
+				Expect(fakeClient.GetFileInfoCalls).To(BeEmpty())
+			})
+		})
+
+				Describe("when the file was removed before it was verified", func() {
+			BeforeEach(func() {
+				options.SkipUnchanged = false
+				upload()
+			})
+
+			It("Should not have an error", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+	})
+
+	Describe("uploader.NewFileInfoClient(accessToken, getMetadataURL, httpClient)", func() {
+		var server *httptest.Server
+		var statusCode int
+		var responseBody string
+		var requestBody string
+		var authorization string
+		var fileInfo *uploader.FileInfo
+		var err error
+
+		BeforeEach(func() {
+			statusCode, responseBody = 200, "{}"
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				requestBody = string(body)
+				authorization = r.Header.Get("Authorization")
+				w.WriteHeader(statusCode)
+				w.Write([]byte(responseBody))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		getFileInfo := func() {
+			sut := uploader.NewFileInfoClient("access-token", server.URL, nil)
+			fileInfo, err = sut.GetFileInfo("/dashboards/latest.png")
+		}
+
+		It("Should decode the content_hash", func() {
+			responseBody = `{".tag": "file", "path_lower": "/dashboards/latest.png", "rev": "a1", "size": 67, "content_hash": "abc"}`
+			getFileInfo()
+			Expect(err).To(BeNil())
+			Expect(requestBody).To(MatchJSON(`{"path": "/dashboards/latest.png"}`))
+			Expect(authorization).To(Equal("Bearer access-token"))
+			Expect(*fileInfo).To(Equal(uploader.FileInfo{PathLower: "/dashboards/latest.png", Rev: "a1", Size: 67, ContentHash: "abc"}))
+		})
+
+		It("Should return nil when there is no file", func() {
+			statusCode, responseBody = 409, `{"error_summary": "path/not_found/..", "error": {}}`
+			getFileInfo()
+			Expect(err).To(BeNil())
+			Expect(fileInfo).To(BeNil())
+		})
+
+		It("Should have a retryable error when rate limited", func() {
+			statusCode, responseBody = 429, "too_many_requests"
+			getFileInfo()
+
+			var rateLimitedError *uploader.RateLimitedError
+			Expect(errors.As(err, &rateLimitedError)).To(BeTrue())
+		})
+
+		It("Should have an AuthInvalidError when the token is rejected", func() {
+			statusCode, responseBody = 401, "invalid_access_token"
+			getFileInfo()
+
+			var authInvalidError *uploader.AuthInvalidError
+			Expect(errors.As(err, &authInvalidError)).To(BeTrue())
+		})
+	})
+})
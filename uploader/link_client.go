@@ -0,0 +1,158 @@
+package uploader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/apierror"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+)
+
+const (
+	// DefaultSharingURL is the base of the sharing endpoints of the
+	// dropbox API
+	DefaultSharingURL = "https://api.dropboxapi.com/2/sharing/"
+
+	// DefaultLinkSettingsTimeout limits each sharing request when no
+	// http.Client is given
+	DefaultLinkSettingsTimeout = 30 * time.Second
+)
+
+// linkSettingsJSON is sharing.SharedLinkSettings as dropbox expects it.
+// The dropbox client writes a zero Expires as year 1, because omitempty
+// never leaves out a struct, while links that never expire must not
+// have an expires at all
+type linkSettingsJSON struct {
+	RequestedVisibility *sharing.RequestedVisibility `json:"requested_visibility,omitempty"`
+	LinkPassword        string                       `json:"link_password,omitempty"`
+	Expires             *time.Time                   `json:"expires,omitempty"`
+}
+
+func newLinkSettingsJSON(settings *sharing.SharedLinkSettings) *linkSettingsJSON {
+	if settings == nil {
+		return nil
+	}
+
+	settingsJSON := &linkSettingsJSON{
+		RequestedVisibility: settings.RequestedVisibility,
+		LinkPassword:        settings.LinkPassword,
+	}
+	if !settings.Expires.IsZero() {
+		expires := settings.Expires
+		settingsJSON.Expires = &expires
+	}
+	return settingsJSON
+}
+
+// sharingError is the 409 response of a sharing endpoint, with the
+// EndpointError that decodeError looks for
+type sharingError struct {
+	ErrorSummary  string      `json:"error_summary"`
+	EndpointError interface{} `json:"error"`
+}
+
+func (err *sharingError) Error() string {
+	return err.ErrorSummary
+}
+
+// clientWithLinkSettings sends the shared link settings itself, rather
+// than through the dropbox client
+type clientWithLinkSettings struct {
+	Client
+	accessToken string
+	sharingURL  string
+	httpClient  *http.Client
+}
+
+// NewLinkSettingsClient wraps client so that its shared link requests
+// leave out the expiry of links that never expire. The requests are
+// sent to sharingURL, which defaults to DefaultSharingURL
+func NewLinkSettingsClient(client Client, accessToken, sharingURL string, httpClient *http.Client) Client {
+	if sharingURL == "" {
+		sharingURL = DefaultSharingURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultLinkSettingsTimeout}
+	}
+	return &clientWithLinkSettings{client, accessToken, sharingURL, httpClient}
+}
+
+func (client *clientWithLinkSettings) CreateSharedLinkWithSettings(arg *sharing.CreateSharedLinkWithSettingsArg) (*sharing.SharedLinkMetadata, error) {
+	body := struct {
+		Path     string            `json:"path"`
+		Settings *linkSettingsJSON `json:"settings,omitempty"`
+	}{arg.Path, newLinkSettingsJSON(arg.Settings)}
+	return client.call("create_shared_link_with_settings", body, &sharing.CreateSharedLinkWithSettingsError{})
+}
+
+func (client *clientWithLinkSettings) ModifySharedLinkSettings(arg *sharing.ModifySharedLinkSettingsArgs) (*sharing.SharedLinkMetadata, error) {
+	body := struct {
+		URL      string            `json:"url"`
+		Settings *linkSettingsJSON `json:"settings"`
+	}{arg.Url, newLinkSettingsJSON(arg.Settings)}
+	return client.call("modify_shared_link_settings", body, &sharing.ModifySharedLinkSettingsError{})
+}
+
+// call posts the body to the sharing endpoint, and returns the errors
+// the dropbox client would have, decoding a 409 into endpointError
+func (client *clientWithLinkSettings) call(endpoint string, body interface{}, endpointError interface{}) (*sharing.SharedLinkMetadata, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", strings.TrimSuffix(client.sharingURL, "/")+"/"+endpoint, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+client.accessToken)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case response.StatusCode == http.StatusOK:
+		var link sharing.SharedLinkMetadata
+		err = json.Unmarshal(responseBody, &link)
+		if err != nil {
+			return nil, &ServerError{Err: err}
+		}
+		return &link, nil
+	case response.StatusCode == http.StatusConflict:
+		conflict := &sharingError{EndpointError: endpointError}
+		err = json.Unmarshal(responseBody, conflict)
+		if err != nil {
+			return nil, &ServerError{Err: err}
+		}
+		return nil, conflict
+	case response.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(response.Header.Get("Retry-After"))
+		return nil, &RateLimitedError{RetryAfter: time.Duration(seconds) * time.Second, Err: fmt.Errorf("%v", strings.TrimSpace(string(responseBody)))}
+	case response.StatusCode >= 500:
+		return nil, &ServerError{Err: fmt.Errorf("%v %v", response.StatusCode, strings.TrimSpace(string(responseBody)))}
+	case response.StatusCode == http.StatusBadRequest:
+		return nil, apierror.ApiError{ErrorSummary: strings.TrimSpace(string(responseBody))}
+	}
+
+	var apiError apierror.ApiError
+	json.Unmarshal(responseBody, &apiError)
+	if apiError.ErrorSummary == "" {
+		apiError.ErrorSummary = fmt.Sprintf("Unexpected status from dropbox sharing/%v: %v", endpoint, response.StatusCode)
+	}
+	return nil, apiError
+}
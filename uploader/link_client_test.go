@@ -0,0 +1,135 @@
+package uploader_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewLinkSettingsClient", func() {
+	var server *httptest.Server
+	var fakeClient *FakeClient
+	var linkSettings uploader.LinkSettings
+	var responses map[string]string
+	var requestBodies map[string]string
+	var authorization string
+	var url string
+	var err error
+
+	expires := time.Date(2016, 7, 1, 12, 0, 0, 0, time.UTC)
+	link := `{".tag": "file", "url": "https://dropbox.biz/link", "name": "example-2016-01-02.png", "path_lower": "/failures/example-2016-01-02.png"}`
+
+	BeforeEach(func() {
+		responses = map[string]string{"/create_shared_link_with_settings": link}
+		requestBodies = map[string]string{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requestBodies[r.URL.Path] = string(body)
+			authorization = r.Header.Get("Authorization")
+			if r.URL.Path == "/create_shared_link_with_settings" && responses["conflict"] != "" {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(responses["conflict"]))
+				return
+			}
+			w.Write([]byte(responses[r.URL.Path]))
+		}))
+
+		fakeClient = NewFakeClient()
+		fakeClient.UploadSpy.ReturnsFileMetadata = &files.FileMetadata{PathLower: "/failures/example-2016-01-02.png"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		client := uploader.NewLinkSettingsClient(fakeClient, "access-token", server.URL, nil)
+		sut := uploader.NewWithClientAndOptions(client, uploader.Options{LinkSettings: linkSettings})
+		url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+	})
+
+	Describe("with only a visibility", func() {
+		BeforeEach(func() {
+			linkSettings = uploader.LinkSettings{Visibility: "team_only"}
+		})
+
+		It("Should not send an expiry", func() {
+			Expect(err).To(BeNil())
+			Expect(requestBodies["/create_shared_link_with_settings"]).To(MatchJSON(`{
+				"path": "/failures/example-2016-01-02.png",
+				"settings": {"requested_visibility": {".tag": "team_only"}}
+			}`))
+			Expect(authorization).To(Equal("Bearer access-token"))
+		})
+
+		It("Should have the url of the link", func() {
+			Expect(url).To(Equal("https://dropbox.biz/link"))
+		})
+	})
+
+	Describe("with an expiry", func() {
+		BeforeEach(func() {
+			linkSettings = uploader.LinkSettings{Visibility: "team_only", Expires: expires}
+		})
+
+		It("Should send the expiry", func() {
+			Expect(requestBodies["/create_shared_link_with_settings"]).To(MatchJSON(`{
+				"path": "/failures/example-2016-01-02.png",
+				"settings": {"requested_visibility": {".tag": "team_only"}, "expires": "2016-07-01T12:00:00Z"}
+			}`))
+		})
+	})
+
+	Describe("without settings", func() {
+		BeforeEach(func() {
+			linkSettings = uploader.LinkSettings{}
+		})
+
+		It("Should only send the path", func() {
+			Expect(requestBodies["/create_shared_link_with_settings"]).To(MatchJSON(`{"path": "/failures/example-2016-01-02.png"}`))
+		})
+	})
+
+	Describe("when the link already exists", func() {
+		BeforeEach(func() {
+			linkSettings = uploader.LinkSettings{Visibility: "team_only"}
+			responses["conflict"] = `{"error_summary": "shared_link_already_exists/..", "error": {".tag": "shared_link_already_exists"}}`
+			responses["/modify_shared_link_settings"] = link
+			existingLink := sharedLink("https://dropbox.biz/existing", "public", time.Time{})
+			fakeClient.ListSharedLinksSpy.ReturnsListSharedLinksResult = sharing.NewListSharedLinksResult([]*sharing.SharedLinkMetadata{existingLink}, false)
+		})
+
+		It("Should modify the existing link without an expiry", func() {
+			Expect(err).To(BeNil())
+			Expect(requestBodies["/modify_shared_link_settings"]).To(MatchJSON(`{
+				"url": "https://dropbox.biz/existing",
+				"settings": {"requested_visibility": {".tag": "team_only"}}
+			}`))
+		})
+	})
+
+	Describe("when dropbox rate limits the request", func() {
+		BeforeEach(func() {
+			linkSettings = uploader.LinkSettings{}
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusTooManyRequests)
+			})
+		})
+
+		It("Should have a RateLimitedError with the Retry-After", func() {
+			var rateLimitedError *uploader.RateLimitedError
+			Expect(errors.As(err, &rateLimitedError)).To(BeTrue())
+			Expect(rateLimitedError.RetryAfter).To(Equal(7 * time.Second))
+		})
+	})
+})
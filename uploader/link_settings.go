@@ -0,0 +1,83 @@
+package uploader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+)
+
+// LinkSettings describes the shared link created for each upload. The
+// zero value leaves the link at dropbox's defaults: public and never
+// expiring
+type LinkSettings struct {
+	// Visibility is one of "public", "team_only" or "password"
+	Visibility string
+
+	// Password is required to open the link when Visibility is "password"
+	Password string
+
+	// Expires is when the link stops working. A zero time never expires
+	Expires time.Time
+}
+
+// Validate returns an error when the settings cannot be applied to a link
+func (settings LinkSettings) Validate() error {
+	switch settings.Visibility {
+	case "", "public", "team_only":
+		if settings.Password != "" {
+			return fmt.Errorf("A link password requires the password visibility")
+		}
+	case "password":
+		if settings.Password == "" {
+			return fmt.Errorf("The password visibility requires a link password")
+		}
+	default:
+		return fmt.Errorf("Unknown link visibility: %v, expected public, team_only or password", settings.Visibility)
+	}
+
+	return nil
+}
+
+// sharedLinkSettings converts the settings to the form dropbox expects,
+// returning nil when nothing was configured
+func (settings LinkSettings) sharedLinkSettings() *sharing.SharedLinkSettings {
+	if settings.Visibility == "" && settings.Expires.IsZero() {
+		return nil
+	}
+
+	sharedLinkSettings := sharing.NewSharedLinkSettings()
+	if settings.Visibility != "" {
+		sharedLinkSettings.RequestedVisibility = &sharing.RequestedVisibility{Tag: settings.Visibility}
+	}
+	sharedLinkSettings.LinkPassword = settings.Password
+	if !settings.Expires.IsZero() {
+		sharedLinkSettings.Expires = settings.Expires.UTC().Truncate(time.Second)
+	}
+	return sharedLinkSettings
+}
+
+// matches reports whether an existing link already has the settings.
+// Dropbox never reveals a link's password, so password protected links
+// never match and always have their settings reapplied
+func (settings LinkSettings) matches(link *sharing.SharedLinkMetadata) bool {
+	if link.File == nil || settings.Visibility == "password" {
+		return false
+	}
+
+	if settings.Visibility != "" {
+		permissions := link.File.LinkPermissions
+		if permissions == nil || permissions.RequestedVisibility == nil {
+			return false
+		}
+		if permissions.RequestedVisibility.Tag != settings.Visibility {
+			return false
+		}
+	}
+
+	if !settings.Expires.IsZero() {
+		return link.File.Expires.Equal(settings.Expires.Truncate(time.Second))
+	}
+
+	return true
+}
@@ -0,0 +1,167 @@
+package uploader_test
+
+import (
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func sharedLink(url, visibility string, expires time.Time) *sharing.SharedLinkMetadata {
+	linkPermissions := sharing.NewLinkPermissions(true)
+	linkPermissions.RequestedVisibility = &sharing.RequestedVisibility{Tag: visibility}
+	fileLinkMetadata := &sharing.FileLinkMetadata{Url: url, LinkPermissions: linkPermissions, Expires: expires}
+	return &sharing.SharedLinkMetadata{Tag: "file", File: fileLinkMetadata}
+}
+
+var _ = Describe("LinkSettings", func() {
+	var sut uploader.Uploader
+	var fakeClient *FakeClient
+	var url string
+	var err error
+
+	expires := time.Date(2016, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		fakeClient = NewFakeClient()
+		fakeClient.UploadSpy.ReturnsFileMetadata = &files.FileMetadata{PathLower: "/failures/example-2016-01-02.png"}
+	})
+
+	Describe("Validate", func() {
+		It("Should accept the zero value", func() {
+			Expect(uploader.LinkSettings{}.Validate()).To(BeNil())
+		})
+
+		It("Should accept team_only", func() {
+			Expect(uploader.LinkSettings{Visibility: "team_only"}.Validate()).To(BeNil())
+		})
+
+		It("Should reject an unknown visibility", func() {
+			Expect(uploader.LinkSettings{Visibility: "everyone"}.Validate()).NotTo(BeNil())
+		})
+
+		It("Should reject the password visibility without a password", func() {
+			Expect(uploader.LinkSettings{Visibility: "password"}.Validate()).NotTo(BeNil())
+		})
+
+		It("Should reject a password without the password visibility", func() {
+			Expect(uploader.LinkSettings{Visibility: "public", Password: "hunter2"}.Validate()).NotTo(BeNil())
+		})
+	})
+
+	Describe("when no link settings are configured", func() {
+		BeforeEach(func() {
+			fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsSharedLinkMetadata = sharedLink("https://dropbox.biz/new", "public", time.Time{})
+
+			sut = uploader.NewWithClient(fakeClient)
+			url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should not have sent any settings", func() {
+			Expect(fakeClient.CreateSharedLinkWithSettingsSpy.LastCalledWith.Settings).To(BeNil())
+		})
+	})
+
+	Describe("when link settings are configured", func() {
+		BeforeEach(func() {
+			fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsSharedLinkMetadata = sharedLink("https://dropbox.biz/new", "password", expires)
+
+			linkSettings := uploader.LinkSettings{Visibility: "password", Password: "hunter2", Expires: expires.Add(500 * time.Millisecond)}
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{LinkSettings: linkSettings})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have created the link with the settings", func() {
+			settings := fakeClient.CreateSharedLinkWithSettingsSpy.LastCalledWith.Settings
+			Expect(settings.RequestedVisibility.Tag).To(Equal("password"))
+			Expect(settings.LinkPassword).To(Equal("hunter2"))
+			Expect(settings.Expires).To(Equal(expires))
+		})
+
+		It("should return the url", func() {
+			Expect(url).To(Equal("https://dropbox.biz/new"))
+		})
+	})
+
+	Describe("when the link settings are invalid", func() {
+		BeforeEach(func() {
+			linkSettings := uploader.LinkSettings{Visibility: "everyone"}
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{LinkSettings: linkSettings})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should not have uploaded anything", func() {
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(0))
+		})
+
+		It("Should have an error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("when the link already exists", func() {
+		var existingLink *sharing.SharedLinkMetadata
+
+		JustBeforeEach(func() {
+			listSharedLinksResult := sharing.NewListSharedLinksResult([]*sharing.SharedLinkMetadata{existingLink}, false)
+			fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsError = NewSharedLinkAlreadyExistsError()
+			fakeClient.ListSharedLinksSpy.ReturnsListSharedLinksResult = listSharedLinksResult
+			fakeClient.ModifySharedLinkSettingsSpy.ReturnsSharedLinkMetadata = sharedLink("https://dropbox.biz/modified", "team_only", expires)
+
+			linkSettings := uploader.LinkSettings{Visibility: "team_only", Expires: expires}
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{LinkSettings: linkSettings})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		Describe("with matching settings", func() {
+			BeforeEach(func() {
+				existingLink = sharedLink("https://dropbox.biz/existing", "team_only", expires)
+			})
+
+			It("Should not have modified the link", func() {
+				Expect(fakeClient.ModifySharedLinkSettingsSpy.CallCount).To(Equal(0))
+			})
+
+			It("Should have the existing url", func() {
+				Expect(url).To(Equal("https://dropbox.biz/existing"))
+			})
+		})
+
+		Describe("with a different visibility", func() {
+			BeforeEach(func() {
+				existingLink = sharedLink("https://dropbox.biz/existing", "public", expires)
+			})
+
+			It("Should have modified the existing link", func() {
+				spy := fakeClient.ModifySharedLinkSettingsSpy
+				Expect(spy.CallCount).To(Equal(1))
+				Expect(spy.LastCalledWith.Url).To(Equal("https://dropbox.biz/existing"))
+				Expect(spy.LastCalledWith.Settings.RequestedVisibility.Tag).To(Equal("team_only"))
+			})
+
+			It("Should have the modified url", func() {
+				Expect(url).To(Equal("https://dropbox.biz/modified"))
+			})
+
+			It("Should not have an error", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("with a different expiry", func() {
+			BeforeEach(func() {
+				existingLink = sharedLink("https://dropbox.biz/existing", "team_only", time.Time{})
+			})
+
+			It("Should have modified the existing link", func() {
+				spy := fakeClient.ModifySharedLinkSettingsSpy
+				Expect(spy.CallCount).To(Equal(1))
+				Expect(spy.LastCalledWith.Settings.Expires).To(Equal(expires))
+			})
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package uploader_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/apierror"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retrying dropbox requests", func() {
+	var sut uploader.Uploader
+	var fakeClient *FakeClient
+	var sleeps []time.Duration
+	var url string
+	var err error
+
+	BeforeEach(func() {
+		sleeps = nil
+
+		fileLinkMetadata := &sharing.FileLinkMetadata{Url: "https://dropbox.biz/failures/example-2016-01-02.png"}
+
+		fakeClient = NewFakeClient()
+		fakeClient.UploadSpy.ReturnsFileMetadata = &files.FileMetadata{PathLower: "/failures/example-2016-01-02.png"}
+		fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsSharedLinkMetadata = &sharing.SharedLinkMetadata{File: fileLinkMetadata}
+
+		policy := retry.Policy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Second,
+			Sleep: func(duration time.Duration) {
+				sleeps = append(sleeps, duration)
+			},
+		}
+		sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{Retry: policy})
+	})
+
+	Describe("when dropbox rate limits the upload once", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSpy.ReturnsErrors = []error{apierror.ApiError{ErrorSummary: "too_many_requests/.."}}
+			url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have uploaded again after waiting", func() {
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(2))
+			Expect(sleeps).To(HaveLen(1))
+		})
+
+		It("Should have sent the whole content again", func() {
+			Expect(fakeClient.UploadSpy.LastCalledWithContent).To(Equal(sampleImage()))
+		})
+
+		It("should return the url", func() {
+			Expect(url).To(Equal("https://dropbox.biz/failures/example-2016-01-02.png"))
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when dropbox keeps answering with server errors", func() {
+		BeforeEach(func() {
+			var apiError apierror.ApiError
+			serverError := json.Unmarshal([]byte("Internal Server Error"), &apiError)
+			fakeClient.UploadSpy.ReturnsError = serverError
+			url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have given up after MaxAttempts", func() {
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(3))
+		})
+
+		It("Should have an error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("when the upload fails with an error that is not retryable", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSpy.ReturnsError = fmt.Errorf("Error uploading.")
+			url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should not have retried", func() {
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(1))
+			Expect(sleeps).To(BeEmpty())
+		})
+	})
+
+	Describe("when creating the shared link is rate limited", func() {
+		BeforeEach(func() {
+			fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsError = apierror.ApiError{ErrorSummary: "too_many_requests/.."}
+			url, err = sut.Upload("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have retried creating the link", func() {
+			Expect(fakeClient.CreateSharedLinkWithSettingsSpy.CallCount).To(Equal(3))
+		})
+
+		It("Should not have uploaded again", func() {
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(1))
+		})
+	})
+})
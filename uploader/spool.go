@@ -0,0 +1,63 @@
+package uploader
+
+import (
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// Spool reads the whole of reader to write it to hash, and returns the
+// content again to be uploaded. A file that can seek is rewound, while
+// anything else is copied to a temporary file, which is removed when
+// the returned reader is closed. Either way, the content is never held
+// in memory
+func Spool(reader io.Reader, hash hash.Hash) (io.ReadCloser, error) {
+	if seeker, ok := reader.(io.ReadSeeker); ok && canSeek(seeker) {
+		_, err := io.Copy(hash, seeker)
+		if err != nil {
+			return nil, err
+		}
+		_, err = seeker.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(seeker), nil
+	}
+
+	file, err := ioutil.TempFile("", "image-upload-to-dropbox-")
+	if err != nil {
+		return nil, err
+	}
+	spooled := &spooledFile{file}
+
+	_, err = io.Copy(io.MultiWriter(file, hash), reader)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	return spooled, nil
+}
+
+// canSeek is false for files such as pipes, which implement Seek but
+// fail when it is called
+func canSeek(seeker io.Seeker) bool {
+	_, err := seeker.Seek(0, io.SeekCurrent)
+	return err == nil
+}
+
+type spooledFile struct {
+	*os.File
+}
+
+func (file *spooledFile) Close() error {
+	err := file.File.Close()
+	removeErr := os.Remove(file.Name())
+	if err != nil {
+		return err
+	}
+	return removeErr
+}
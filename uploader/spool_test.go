@@ -0,0 +1,84 @@
+package uploader_test
+
+import (
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spool", func() {
+	var data string
+	var sum [sha256.Size]byte
+
+	BeforeEach(func() {
+		data = strings.Repeat("image data ", 1000)
+		sum = sha256.Sum256([]byte(data))
+	})
+
+	Describe("uploader.Spool(stream, hash)", func() {
+		var spooled io.ReadCloser
+		var hash = sha256.New()
+
+		BeforeEach(func() {
+			hash.Reset()
+			var err error
+			spooled, err = uploader.Spool(ioutil.NopCloser(strings.NewReader(data)), hash)
+			Expect(err).To(BeNil())
+		})
+
+		It("Should hash the whole stream", func() {
+			Expect(hash.Sum(nil)).To(Equal(sum[:]))
+		})
+
+		It("Should return the stream again", func() {
+			spooledData, err := ioutil.ReadAll(spooled)
+			Expect(err).To(BeNil())
+			Expect(string(spooledData)).To(Equal(data))
+		})
+
+		It("Should remove the temporary file on Close", func() {
+			name := spooled.(interface{ Name() string }).Name()
+			Expect(spooled.Close()).To(BeNil())
+
+			_, err := os.Stat(name)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("uploader.Spool(file, hash)", func() {
+		var file *os.File
+
+		BeforeEach(func() {
+			var err error
+			file, err = ioutil.TempFile("", "spool-test-")
+			Expect(err).To(BeNil())
+			_, err = file.WriteString(data)
+			Expect(err).To(BeNil())
+			_, err = file.Seek(0, io.SeekStart)
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			file.Close()
+			os.Remove(file.Name())
+		})
+
+		It("Should hash the file and rewind it", func() {
+			hash := sha256.New()
+			spooled, err := uploader.Spool(file, hash)
+			Expect(err).To(BeNil())
+			Expect(hash.Sum(nil)).To(Equal(sum[:]))
+
+			spooledData, err := ioutil.ReadAll(spooled)
+			Expect(err).To(BeNil())
+			Expect(string(spooledData)).To(Equal(data))
+		})
+	})
+})
@@ -0,0 +1,181 @@
+package uploader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+)
+
+const (
+	// DefaultChunkThreshold is the content size in bytes above which an
+	// upload session is used
+	DefaultChunkThreshold = 8 * 1024 * 1024
+
+	// DefaultChunkSize is the size in bytes of each upload session request
+	DefaultChunkSize = 4 * 1024 * 1024
+
+	// MaxRequestSize is the most content in bytes dropbox accepts in a
+	// single upload or upload session request
+	MaxRequestSize = 150 * 1024 * 1024
+
+	// maxOffsetResumes is how many times a single chunk will be resent
+	// after dropbox reports an incorrect offset
+	maxOffsetResumes = 3
+)
+
+// ValidateChunkSizes returns an error when a single upload or upload
+// session request could be larger than dropbox accepts. Zero sizes are
+// replaced with the defaults, and are valid
+func ValidateChunkSizes(chunkThreshold, chunkSize int64) error {
+	if chunkThreshold > MaxRequestSize {
+		return fmt.Errorf("The chunk threshold of %v bytes is larger than the %v bytes dropbox accepts in one request", chunkThreshold, int64(MaxRequestSize))
+	}
+	if chunkSize > MaxRequestSize {
+		return fmt.Errorf("The chunk size of %v bytes is larger than the %v bytes dropbox accepts in one request", chunkSize, int64(MaxRequestSize))
+	}
+	return nil
+}
+
+// upload sends the content in a single request when it fits under the
+// chunk threshold, and through an upload session otherwise
+func (uploader *dropBoxUploader) upload(commitInfo *files.CommitInfo, content io.Reader) (*files.FileMetadata, error) {
+	head, err := ioutil.ReadAll(io.LimitReader(content, uploader.options.ChunkThreshold+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(head)) <= uploader.options.ChunkThreshold {
+		var fileMetadata *files.FileMetadata
+		err = uploader.retry(func() error {
+			var err error
+			fileMetadata, err = uploader.client.Upload(commitInfo, bytes.NewReader(head))
+			return err
+		})
+		return fileMetadata, err
+	}
+
+	return uploader.uploadSession(commitInfo, io.MultiReader(bytes.NewReader(head), content))
+}
+
+func (uploader *dropBoxUploader) uploadSession(commitInfo *files.CommitInfo, content io.Reader) (*files.FileMetadata, error) {
+	chunk := make([]byte, uploader.options.ChunkSize)
+
+	n, err := io.ReadFull(content, chunk)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	var startResult *files.UploadSessionStartResult
+	err = uploader.retry(func() error {
+		var err error
+		startResult, err = uploader.client.UploadSessionStart(bytes.NewReader(chunk[:n]))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID := startResult.SessionId
+	offset := uint64(n)
+
+	for {
+		n, err = io.ReadFull(content, chunk)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = resumable(offset, chunk[:n], func(offset uint64, data []byte) error {
+			cursor := files.NewUploadSessionCursor(sessionID, offset)
+			return uploader.retry(func() error {
+				return uploader.client.UploadSessionAppend(cursor, bytes.NewReader(data))
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		offset += uint64(n)
+	}
+
+	var fileMetadata *files.FileMetadata
+	err = resumable(offset, chunk[:n], func(offset uint64, data []byte) error {
+		cursor := files.NewUploadSessionCursor(sessionID, offset)
+		finishArg := files.NewUploadSessionFinishArg(cursor, commitInfo)
+
+		return uploader.retry(func() error {
+			var err error
+			fileMetadata, err = uploader.client.UploadSessionFinish(finishArg, bytes.NewReader(data))
+			return err
+		})
+	})
+	return fileMetadata, err
+}
+
+// resumable calls send with the chunk that begins at offset. If dropbox
+// responds that it has already received part of the chunk, send is called
+// again with the remainder, starting at the offset dropbox reported
+func resumable(offset uint64, chunk []byte, send func(offset uint64, chunk []byte) error) error {
+	end := offset + uint64(len(chunk))
+
+	for resumes := 0; ; resumes++ {
+		err := send(offset, chunk)
+		if err == nil {
+			return nil
+		}
+
+		correctOffset, ok := incorrectOffset(err)
+		if !ok || resumes >= maxOffsetResumes || correctOffset < offset || correctOffset > end {
+			return err
+		}
+
+		chunk = chunk[correctOffset-offset:]
+		offset = correctOffset
+	}
+}
+
+// incorrectOffset returns the offset dropbox expected when err is an
+// upload session lookup failure caused by an incorrect offset
+func incorrectOffset(err error) (uint64, bool) {
+	var lookupError *files.UploadSessionLookupError
+
+	switch endpointError := endpointError(err).(type) {
+	case *files.UploadSessionLookupError:
+		lookupError = endpointError
+	case *files.UploadSessionFinishError:
+		if endpointError != nil {
+			lookupError = endpointError.LookupFailed
+		}
+	}
+
+	if lookupError == nil || lookupError.Tag != "incorrect_offset" || lookupError.IncorrectOffset == nil {
+		return 0, false
+	}
+	return lookupError.IncorrectOffset.CorrectOffset, true
+}
+
+// endpointError extracts the route specific error the dropbox client
+// decodes into the EndpointError field of the errors it returns
+func endpointError(err error) interface{} {
+	value := reflect.ValueOf(err)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	field := value.FieldByName("EndpointError")
+	if !field.IsValid() || !field.CanInterface() {
+		return nil
+	}
+	return field.Interface()
+}
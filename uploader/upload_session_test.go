@@ -0,0 +1,261 @@
+package uploader_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	imagecontent "github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/content"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func incorrectOffsetError(correctOffset uint64) error {
+	lookupError := &files.UploadSessionLookupError{
+		Tag:             "incorrect_offset",
+		IncorrectOffset: files.NewUploadSessionOffsetError(correctOffset),
+	}
+	return FakeAPIError{EndpointError: lookupError}
+}
+
+// countingReader counts how much has been read from it
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.reader.Read(p)
+	reader.count += int64(n)
+	return n, err
+}
+
+// StreamingClient records how much of the source had been read when the
+// upload session started
+type StreamingClient struct {
+	*FakeClient
+	Source      *countingReader
+	ReadAtStart int64
+}
+
+func (client *StreamingClient) UploadSessionStart(content io.Reader) (*files.UploadSessionStartResult, error) {
+	client.ReadAtStart = client.Source.count
+	return client.FakeClient.UploadSessionStart(content)
+}
+
+var _ = Describe("Upload sessions", func() {
+	var sut uploader.Uploader
+	var fakeClient *FakeClient
+	var content []byte
+	var url string
+	var err error
+
+	BeforeEach(func() {
+		content = []byte("0123456789abcdefghij")
+
+		fileMetadata := &files.FileMetadata{PathLower: "/failures/example-2016-01-02.png"}
+		fileLinkMetadata := &sharing.FileLinkMetadata{Url: "https://dropbox.biz/failures/example-2016-01-02.png"}
+		sharedLinkMetadata := &sharing.SharedLinkMetadata{File: fileLinkMetadata}
+
+		fakeClient = NewFakeClient()
+		fakeClient.UploadSpy.ReturnsFileMetadata = fileMetadata
+		fakeClient.UploadSessionStartSpy.ReturnsUploadSessionStartResult = files.NewUploadSessionStartResult("session-id")
+		fakeClient.UploadSessionFinishSpy.ReturnsFileMetadata = fileMetadata
+		fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsSharedLinkMetadata = sharedLinkMetadata
+	})
+
+	Describe("when the chunk size is larger than dropbox accepts", func() {
+		BeforeEach(func() {
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{ChunkSize: uploader.MaxRequestSize + 1})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", bytes.NewReader(content))
+		})
+
+		It("Should have an error before uploading anything", func() {
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("The chunk size of 157286401 bytes is larger than the 157286400 bytes dropbox accepts in one request"))
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(0))
+		})
+	})
+
+	Describe("when the content is under the chunk threshold", func() {
+		BeforeEach(func() {
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{ChunkThreshold: 20, ChunkSize: 8})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", bytes.NewReader(content))
+		})
+
+		It("Should have called fakeClient.Upload", func() {
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(1))
+		})
+
+		It("Should not have started an upload session", func() {
+			Expect(fakeClient.UploadSessionStartSpy.CallCount).To(Equal(0))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when the content is over the chunk threshold", func() {
+		BeforeEach(func() {
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{ChunkThreshold: 10, ChunkSize: 8})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", bytes.NewReader(content))
+		})
+
+		It("Should not have called fakeClient.Upload", func() {
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(0))
+		})
+
+		It("Should have started the session with the first chunk", func() {
+			spy := fakeClient.UploadSessionStartSpy
+			Expect(spy.CallCount).To(Equal(1))
+			Expect(string(spy.LastCalledWithContent)).To(Equal("01234567"))
+		})
+
+		It("Should have appended the second chunk at the right offset", func() {
+			spy := fakeClient.UploadSessionAppendSpy
+			Expect(spy.CallCount).To(Equal(1))
+			Expect(spy.CalledWithCursors[0].SessionId).To(Equal("session-id"))
+			Expect(spy.CalledWithCursors[0].Offset).To(Equal(uint64(8)))
+			Expect(string(spy.CalledWithContents[0])).To(Equal("89abcdef"))
+		})
+
+		It("Should have finished the session with the remainder and the commit info", func() {
+			spy := fakeClient.UploadSessionFinishSpy
+			Expect(spy.CallCount).To(Equal(1))
+			Expect(spy.CalledWith[0].Cursor.Offset).To(Equal(uint64(16)))
+			Expect(spy.CalledWith[0].Commit.Path).To(Equal("/failures/example-2016-01-02.png"))
+			Expect(spy.CalledWith[0].Commit.Mode.Tag).To(Equal("overwrite"))
+			Expect(string(spy.CalledWithContents[0])).To(Equal("ghij"))
+		})
+
+		It("Should have created a shared link for the uploaded file", func() {
+			spy := fakeClient.CreateSharedLinkWithSettingsSpy
+			Expect(spy.LastCalledWith.Path).To(Equal("/failures/example-2016-01-02.png"))
+		})
+
+		It("should return the url", func() {
+			Expect(url).To(Equal("https://dropbox.biz/failures/example-2016-01-02.png"))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when an append reports an incorrect offset", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSessionAppendSpy.ReturnsErrors = []error{incorrectOffsetError(11)}
+
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{ChunkThreshold: 10, ChunkSize: 8})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", bytes.NewReader(content))
+		})
+
+		It("Should have resumed from the correct offset", func() {
+			spy := fakeClient.UploadSessionAppendSpy
+			Expect(spy.CallCount).To(Equal(2))
+			Expect(spy.CalledWithCursors[1].Offset).To(Equal(uint64(11)))
+			Expect(string(spy.CalledWithContents[1])).To(Equal("bcdef"))
+		})
+
+		It("Should have finished the session after the resumed chunk", func() {
+			spy := fakeClient.UploadSessionFinishSpy
+			Expect(spy.CallCount).To(Equal(1))
+			Expect(spy.CalledWith[0].Cursor.Offset).To(Equal(uint64(16)))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when finishing reports an incorrect offset", func() {
+		BeforeEach(func() {
+			finishError := &files.UploadSessionFinishError{
+				Tag: "lookup_failed",
+				LookupFailed: &files.UploadSessionLookupError{
+					Tag:             "incorrect_offset",
+					IncorrectOffset: files.NewUploadSessionOffsetError(18),
+				},
+			}
+			fakeClient.UploadSessionFinishSpy.ReturnsErrors = []error{FakeAPIError{EndpointError: finishError}}
+
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{ChunkThreshold: 10, ChunkSize: 8})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", bytes.NewReader(content))
+		})
+
+		It("Should have resumed the finish from the correct offset", func() {
+			spy := fakeClient.UploadSessionFinishSpy
+			Expect(spy.CallCount).To(Equal(2))
+			Expect(spy.CalledWith[1].Cursor.Offset).To(Equal(uint64(18)))
+			Expect(string(spy.CalledWithContents[1])).To(Equal("ij"))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when an append reports an offset outside of the chunk", func() {
+		BeforeEach(func() {
+			fakeClient.UploadSessionAppendSpy.ReturnsErrors = []error{incorrectOffsetError(2)}
+
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{ChunkThreshold: 10, ChunkSize: 8})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", bytes.NewReader(content))
+		})
+
+		It("Should not have retried the append", func() {
+			Expect(fakeClient.UploadSessionAppendSpy.CallCount).To(Equal(1))
+		})
+
+		It("Should have an empty url", func() {
+			Expect(url).To(Equal(""))
+		})
+
+		It("Should have an error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("when base64 from stdin is streamed the way the command line does", func() {
+		var streamingClient *StreamingClient
+		var image []byte
+
+		BeforeEach(func() {
+			image = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte("0123456789abcdef"), 256*1024)...)
+			source := &countingReader{reader: strings.NewReader(base64.StdEncoding.EncodeToString(image))}
+			streamingClient = &StreamingClient{FakeClient: fakeClient, Source: source}
+
+			reader, openErr := imagecontent.Open(imagecontent.Stdin, source)
+			Expect(openErr).To(BeNil())
+
+			sut = uploader.NewWithClientAndOptions(streamingClient, uploader.Options{ChunkThreshold: 64 * 1024, ChunkSize: 64 * 1024})
+			url, err = sut.Upload("/failures/example-2016-01-02.png", reader)
+		})
+
+		It("Should start uploading before the whole image was read", func() {
+			Expect(streamingClient.ReadAtStart).To(BeNumerically("<", 256*1024))
+			Expect(streamingClient.Source.count).To(BeNumerically(">", 4*1024*1024))
+		})
+
+		It("Should upload all of the image", func() {
+			uploaded := append([]byte{}, fakeClient.UploadSessionStartSpy.LastCalledWithContent...)
+			for _, chunk := range fakeClient.UploadSessionAppendSpy.CalledWithContents {
+				uploaded = append(uploaded, chunk...)
+			}
+			for _, chunk := range fakeClient.UploadSessionFinishSpy.CalledWithContents {
+				uploaded = append(uploaded, chunk...)
+			}
+			Expect(uploaded).To(Equal(image))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+})
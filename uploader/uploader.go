@@ -2,14 +2,18 @@ package uploader
 
 import (
 	"bytes"
-	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/dropbox/dropbox-sdk-go-unofficial"
 	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
 	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/retry"
 )
 
 // Uploader defines the interface for uploading a file
@@ -18,68 +22,434 @@ type Uploader interface {
 	Upload(filepath string, content io.Reader) (string, error)
 
 	// UploadBase64 takes a base64 encoded file as a string and uploads it
-	// to dropbox at the given remote filepath
+	// to dropbox at the given remote filepath, decoding it as it is sent
 	UploadBase64(filepath, contentStrBase64 string) (string, error)
+
+	// UploadWithResult uploads a file like Upload, and describes where
+	// dropbox stored it
+	UploadWithResult(filepath string, content io.Reader) (*Result, error)
+
+	// UploadBase64WithResult uploads a base64 encoded file like
+	// UploadBase64, and describes where dropbox stored it
+	UploadBase64WithResult(filepath, contentStrBase64 string) (*Result, error)
+}
+
+// Result describes a completed upload
+type Result struct {
+	// URL is the shared link to the uploaded file
+	URL string `json:"url"`
+
+	// DirectURL is a link that serves the file itself instead of a
+	// preview page
+	DirectURL string `json:"direct_url"`
+
+	// Path is where dropbox stored the file, its path_display, which
+	// differs from the requested path when the file was autorenamed or
+	// its extension was fixed
+	Path string `json:"path"`
+
+	// Rev identifies this revision of the file, as used by the
+	// "update:<rev>" write mode
+	Rev string `json:"rev"`
+
+	// Size is the size of the file in bytes
+	Size int64 `json:"size"`
+
+	// ServerModified is when dropbox stored the file
+	ServerModified time.Time `json:"server_modified"`
+
+	// ContentHash is the dropbox content_hash of the file. The dropbox
+	// client does not return it, so it is computed from the content
+	// that was sent, and checked against dropbox's when the client is a
+	// FileInfoClient
+	ContentHash string `json:"content_hash"`
+
+	// Visibility is who can open the shared link, such as "public" or
+	// "team_only", and Expires is when it stops working. Expires is nil
+	// for links that never expire
+	Visibility string     `json:"visibility,omitempty"`
+	Expires    *time.Time `json:"expires,omitempty"`
+
+	// MIMEType, Width and Height describe the image sniffed from the
+	// content. MIMEType is empty when it was not a recognized image
+	MIMEType string `json:"mime_type,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+
+	// Unchanged is true when the upload was skipped because the file
+	// already had the same content, see Options.SkipUnchanged
+	Unchanged bool `json:"unchanged"`
 }
 
 // Client defines the interface of the client the Uploader will use
 type Client interface {
 	CreateSharedLinkWithSettings(arg *sharing.CreateSharedLinkWithSettingsArg) (res *sharing.SharedLinkMetadata, err error)
 	ListSharedLinks(arg *sharing.ListSharedLinksArg) (res *sharing.ListSharedLinksResult, err error)
+	ModifySharedLinkSettings(arg *sharing.ModifySharedLinkSettingsArgs) (res *sharing.SharedLinkMetadata, err error)
 	Upload(arg *files.CommitInfo, content io.Reader) (res *files.FileMetadata, err error)
+	UploadSessionAppend(arg *files.UploadSessionCursor, content io.Reader) (err error)
+	UploadSessionFinish(arg *files.UploadSessionFinishArg, content io.Reader) (res *files.FileMetadata, err error)
+	UploadSessionStart(content io.Reader) (res *files.UploadSessionStartResult, err error)
+}
+
+// Options configures the behavior of an Uploader. Zero values are
+// replaced with the defaults
+type Options struct {
+	// ChunkThreshold is the size in bytes above which content is sent
+	// using an upload session instead of a single upload request
+	ChunkThreshold int64
+
+	// ChunkSize is the size in bytes of each upload session request.
+	// Neither it nor ChunkThreshold may be over MaxRequestSize
+	ChunkSize int64
+
+	// LinkSettings are applied to the shared link of every upload
+	LinkSettings LinkSettings
+
+	// WriteMode is one of "add", "overwrite" or "update:<rev>" and
+	// defaults to "overwrite"
+	WriteMode string
+
+	// Autorename lets dropbox pick a new name instead of failing when
+	// the write mode conflicts with an existing file
+	Autorename bool
+
+	// RequireImage rejects content that is not a PNG, JPEG, GIF, WebP,
+	// BMP or SVG image
+	RequireImage bool
+
+	// MaxSize is the largest content in bytes that will be uploaded. Zero
+	// means no maximum
+	MaxSize int64
+
+	// MaxWidth and MaxHeight are the largest image dimensions in pixels
+	// that will be uploaded. Zero means no maximum
+	MaxWidth  int
+	MaxHeight int
+
+	// FixExtension adds the extension of the image type to a path that
+	// has none, and replaces the extension of another image type
+	FixExtension bool
+
+	// SkipUnchanged leaves the file in dropbox as it is, and only
+	// returns its link, when it already has the same content_hash as the
+	// content. The content is spooled to a temporary file to hash it
+	// before uploading
+	SkipUnchanged bool
+
+	// VerifyContentHash checks the content_hash dropbox computed for the
+	// uploaded file against the content that was sent
+	VerifyContentHash bool
+
+	// Retry is applied to every request made to dropbox. When it has no
+	// Retryable func, rate limits, server errors and network errors are
+	// retried
+	Retry retry.Policy
 }
 
 type dropBoxUploader struct {
-	client Client
+	client  Client
+	options Options
 }
 
 // New constructs a new Uploader instance using the dropbox client
 func New(accessToken string) Uploader {
-	client := dropbox.Client(accessToken, dropbox.Options{})
-	return &dropBoxUploader{client}
+	return NewWithOptions(accessToken, Options{})
+}
+
+// NewWithOptions constructs a new Uploader instance using the dropbox
+// client and the given options
+func NewWithOptions(accessToken string, options Options) Uploader {
+	client := NewLinkSettingsClient(dropbox.Client(accessToken, dropbox.Options{}), accessToken, "", nil)
+	if options.SkipUnchanged || options.VerifyContentHash {
+		client = &clientWithFileInfo{client, NewFileInfoClient(accessToken, "", nil)}
+	}
+	return NewWithClientAndOptions(client, options)
 }
 
-// NewWithClient constructs a new Uploader instance using the given client
+// NewWithClient constructs a new Uploader instance using the given
+// client. Unchanged uploads are only skipped, and uploads only verified,
+// when the client is also a FileInfoClient
 func NewWithClient(client Client) Uploader {
-	return &dropBoxUploader{client}
+	return NewWithClientAndOptions(client, Options{})
+}
+
+// NewWithClientAndOptions constructs a new Uploader instance using the
+// given client and options
+func NewWithClientAndOptions(client Client, options Options) Uploader {
+	if options.ChunkThreshold <= 0 {
+		options.ChunkThreshold = DefaultChunkThreshold
+	}
+	if options.ChunkSize <= 0 {
+		options.ChunkSize = DefaultChunkSize
+	}
+	if options.Retry.Retryable == nil {
+		options.Retry.Retryable = isRetryable
+	}
+	return &dropBoxUploader{client, options}
 }
 
 func (uploader *dropBoxUploader) Upload(filepath string, content io.Reader) (string, error) {
-	commitInfo := files.NewCommitInfo(filepath)
-	commitInfo.Mode = &files.WriteMode{Tag: "overwrite"}
-	fileMetadata, err := uploader.client.Upload(commitInfo, content)
+	result, err := uploader.UploadWithResult(filepath, content)
 	if err != nil {
 		return "", err
 	}
 
-	settings := sharing.NewCreateSharedLinkWithSettingsArg(fileMetadata.PathLower)
-	sharedLinkMetadata, err := uploader.client.CreateSharedLinkWithSettings(settings)
+	return result.URL, nil
+}
+
+func (uploader *dropBoxUploader) UploadWithResult(filepath string, content io.Reader) (*Result, error) {
+	err := uploader.options.LinkSettings.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ValidateChunkSizes(uploader.options.ChunkThreshold, uploader.options.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	writeMode, err := ParseWriteMode(uploader.options.WriteMode)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := ioutil.ReadAll(io.LimitReader(content, SniffLen))
+	if err != nil {
+		return nil, err
+	}
+	contentType := SniffContentType(head)
+	err = uploader.validate(filepath, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if uploader.options.FixExtension {
+		filepath = FixExtension(filepath, contentType)
+	}
+
+	content = io.MultiReader(bytes.NewReader(head), content)
+	if uploader.options.MaxSize > 0 {
+		content = &maxSizeReader{reader: content, path: filepath, max: uploader.options.MaxSize}
+	}
+
+	contentHash := NewContentHash()
+	fileInfoClient, _ := uploader.client.(FileInfoClient)
+	if !uploader.options.SkipUnchanged || fileInfoClient == nil {
+		content = io.TeeReader(content, contentHash)
+	} else {
+		spooled, err := Spool(content, contentHash)
+		if err != nil {
+			return nil, err
+		}
+		defer spooled.Close()
+		content = spooled
+
+		existing, err := uploader.getFileInfo(fileInfoClient, filepath)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.ContentHash == hex.EncodeToString(contentHash.Sum(nil)) {
+			return uploader.result(existing, contentType, true)
+		}
+	}
+
+	commitInfo := files.NewCommitInfo(filepath)
+	commitInfo.Mode = writeMode
+	commitInfo.Autorename = uploader.options.Autorename
+	fileMetadata, err := uploader.upload(commitInfo, content)
+	if err != nil {
+		return nil, uploadError(err, commitInfo)
+	}
+
+	uploaded := &FileInfo{
+		PathLower:      fileMetadata.PathLower,
+		PathDisplay:    fileMetadata.PathDisplay,
+		Rev:            fileMetadata.Rev,
+		Size:           int64(fileMetadata.Size),
+		ServerModified: fileMetadata.ServerModified,
+		ContentHash:    hex.EncodeToString(contentHash.Sum(nil)),
+	}
+	if uploader.options.VerifyContentHash && fileInfoClient != nil {
+		err = uploader.verify(fileInfoClient, uploaded)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return uploader.result(uploaded, contentType, false)
+}
+
+// getFileInfo gets the FileInfo of the file at path, or nil when there
+// is none
+func (uploader *dropBoxUploader) getFileInfo(fileInfoClient FileInfoClient, path string) (*FileInfo, error) {
+	var fileInfo *FileInfo
+	err := uploader.retry(func() error {
+		var err error
+		fileInfo, err = fileInfoClient.GetFileInfo(path)
+		return err
+	})
+	return fileInfo, err
+}
+
+// verify checks the content_hash dropbox computed for the uploaded file
+// against the one computed from the content that was sent. When the file
+// is at another revision, or gone, someone else wrote to the path since
+// the upload, and there is nothing left to verify
+func (uploader *dropBoxUploader) verify(fileInfoClient FileInfoClient, uploaded *FileInfo) error {
+	stored, err := uploader.getFileInfo(fileInfoClient, uploaded.PathLower)
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.Rev != uploaded.Rev {
+		return nil
+	}
+	if stored.ContentHash != uploaded.ContentHash {
+		return &ContentHashMismatchError{Path: uploaded.PathDisplay, Local: uploaded.ContentHash, Server: stored.ContentHash}
+	}
+	return nil
+}
+
+// result links to the file and describes it
+func (uploader *dropBoxUploader) result(fileInfo *FileInfo, contentType *ContentType, unchanged bool) (*Result, error) {
+	link, err := uploader.sharedLink(fileInfo.PathLower)
+	if err != nil {
+		return nil, err
+	}
+
+	directURL, err := DirectURL(link.Url, "raw")
+	if err != nil {
+		return nil, err
+	}
+
+	path := fileInfo.PathDisplay
+	if path == "" {
+		path = fileInfo.PathLower
+	}
+
+	var expires *time.Time
+	if !link.Expires.IsZero() {
+		expires = &link.Expires
+	}
+
+	return &Result{
+		URL:            link.Url,
+		DirectURL:      directURL,
+		Path:           path,
+		Rev:            fileInfo.Rev,
+		Size:           fileInfo.Size,
+		ServerModified: fileInfo.ServerModified,
+		ContentHash:    fileInfo.ContentHash,
+		Visibility:     linkVisibility(link),
+		Expires:        expires,
+		MIMEType:       contentType.MIMEType,
+		Width:          contentType.Width,
+		Height:         contentType.Height,
+		Unchanged:      unchanged,
+	}, nil
+}
+
+// linkVisibility is who can open the link once team and shared folder
+// policies are applied, or the visibility that was asked for when
+// dropbox does not say
+func linkVisibility(link *sharing.FileLinkMetadata) string {
+	permissions := link.LinkPermissions
+	switch {
+	case permissions == nil:
+		return ""
+	case permissions.ResolvedVisibility != nil:
+		return permissions.ResolvedVisibility.Tag
+	case permissions.RequestedVisibility != nil:
+		return permissions.RequestedVisibility.Tag
+	}
+	return ""
+}
+
+// validate rejects content that is not an image when one is required,
+// and images larger than the maximum dimensions
+func (uploader *dropBoxUploader) validate(filepath string, contentType *ContentType) error {
+	if uploader.options.RequireImage && !contentType.IsImage() {
+		return &NotAnImageError{Path: filepath}
+	}
+
+	maxWidth, maxHeight := uploader.options.MaxWidth, uploader.options.MaxHeight
+	tooWide := maxWidth > 0 && contentType.Width > maxWidth
+	tooTall := maxHeight > 0 && contentType.Height > maxHeight
+	if tooWide || tooTall {
+		return &DimensionsTooLargeError{
+			Path:      filepath,
+			Width:     contentType.Width,
+			Height:    contentType.Height,
+			MaxWidth:  maxWidth,
+			MaxHeight: maxHeight,
+		}
+	}
+	return nil
+}
+
+// retry calls fn, a request to dropbox, according to the retry policy
+func (uploader *dropBoxUploader) retry(fn func() error) error {
+	return uploader.options.Retry.Do(fn)
+}
+
+// sharedLink creates a shared link to the file at path, or reuses the
+// link that already exists, and returns it
+func (uploader *dropBoxUploader) sharedLink(path string) (*sharing.FileLinkMetadata, error) {
+	settings := uploader.options.LinkSettings.sharedLinkSettings()
+
+	createSharedLinkArg := sharing.NewCreateSharedLinkWithSettingsArg(path)
+	createSharedLinkArg.Settings = settings
+	var sharedLinkMetadata *sharing.SharedLinkMetadata
+	err := uploader.retry(func() error {
+		var err error
+		sharedLinkMetadata, err = uploader.client.CreateSharedLinkWithSettings(createSharedLinkArg)
+		return err
+	})
 	if err == nil {
-		return sharedLinkMetadata.File.Url, nil
+		return sharedLinkMetadata.File, nil
 	}
-	if !strings.HasPrefix(err.Error(), "shared_link_already_exists") {
-		return "", err
+	err = decodeError(err, path)
+	var linkAlreadyExistsError *LinkAlreadyExistsError
+	if !errors.As(err, &linkAlreadyExistsError) {
+		return nil, err
 	}
 
 	listSharedLinksArg := sharing.NewListSharedLinksArg()
-	listSharedLinksArg.Path = fileMetadata.PathLower
-	listSharedLinksResult, err := uploader.client.ListSharedLinks(listSharedLinksArg)
+	listSharedLinksArg.Path = path
+	var listSharedLinksResult *sharing.ListSharedLinksResult
+	err = uploader.retry(func() error {
+		var err error
+		listSharedLinksResult, err = uploader.client.ListSharedLinks(listSharedLinksArg)
+		return err
+	})
 	if err != nil {
-		return "", err
+		return nil, decodeError(err, path)
 	}
 	if len(listSharedLinksResult.Links) == 0 {
-		return "", fmt.Errorf("Shared Link already existed, but could not retrieve it")
+		return nil, fmt.Errorf("Shared Link already existed, but could not retrieve it")
 	}
 
-	return listSharedLinksResult.Links[0].File.Url, nil
-}
+	existingLink := listSharedLinksResult.Links[0]
+	if settings == nil || uploader.options.LinkSettings.matches(existingLink) {
+		return existingLink.File, nil
+	}
 
-func (uploader *dropBoxUploader) UploadBase64(filepath string, contentStrBase64 string) (string, error) {
-	data, err := base64.StdEncoding.DecodeString(contentStrBase64)
+	modifyArgs := sharing.NewModifySharedLinkSettingsArgs(existingLink.File.Url, settings)
+	var modifiedLink *sharing.SharedLinkMetadata
+	err = uploader.retry(func() error {
+		var err error
+		modifiedLink, err = uploader.client.ModifySharedLinkSettings(modifyArgs)
+		return err
+	})
 	if err != nil {
-		return "", err
+		return nil, decodeError(err, path)
 	}
 
-	content := bytes.NewReader(data)
-	return uploader.Upload(filepath, content)
+	return modifiedLink.File, nil
+}
+
+func (uploader *dropBoxUploader) UploadBase64(filepath string, contentStrBase64 string) (string, error) {
+	return uploader.Upload(filepath, NewBase64Decoder(strings.NewReader(contentStrBase64)))
+}
+
+func (uploader *dropBoxUploader) UploadBase64WithResult(filepath string, contentStrBase64 string) (*Result, error) {
+	return uploader.UploadWithResult(filepath, NewBase64Decoder(strings.NewReader(contentStrBase64)))
 }
@@ -3,8 +3,10 @@ package uploader_test
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
 	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
@@ -178,7 +180,7 @@ var _ = Describe("Uploader", func() {
 
 				fakeClient = NewFakeClient()
 				fakeClient.UploadSpy.ReturnsFileMetadata = fileMetadata
-				fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsError = fmt.Errorf("shared_link_already_exists/..")
+				fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsError = NewSharedLinkAlreadyExistsError()
 				fakeClient.ListSharedLinksSpy.ReturnsListSharedLinksResult = listSharedLinksResult
 
 				sut = uploader.NewWithClient(fakeClient)
@@ -210,7 +212,7 @@ var _ = Describe("Uploader", func() {
 
 				fakeClient = NewFakeClient()
 				fakeClient.UploadSpy.ReturnsFileMetadata = fileMetadata
-				fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsError = fmt.Errorf("shared_link_already_exists/..")
+				fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsError = NewSharedLinkAlreadyExistsError()
 				fakeClient.ListSharedLinksSpy.ReturnsListSharedLinksResult = listSharedLinksResult
 
 				sut = uploader.NewWithClient(fakeClient)
@@ -265,4 +267,54 @@ var _ = Describe("Uploader", func() {
 			})
 		})
 	})
+
+	Describe("sut.UploadWithResult(filepath, content)", func() {
+		var result *uploader.Result
+		serverModified := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+		expires := time.Date(2016, 7, 1, 12, 0, 0, 0, time.UTC)
+
+		BeforeEach(func() {
+			fileMetadata := &files.FileMetadata{
+				PathLower:      "/failures/example-2016-01-02.png",
+				PathDisplay:    "/Failures/example-2016-01-02.png",
+				Rev:            "a1c10ce0dd78",
+				Size:           67,
+				ServerModified: serverModified,
+			}
+
+			link := sharedLink("https://www.dropbox.com/s/abc/example-2016-01-02.png?dl=0", "public", expires)
+			link.File.LinkPermissions.ResolvedVisibility = &sharing.ResolvedVisibility{Tag: "team_only"}
+
+			fakeClient = NewFakeClient()
+			fakeClient.UploadSpy.ReturnsFileMetadata = fileMetadata
+			fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsSharedLinkMetadata = link
+
+			sut = uploader.NewWithClient(fakeClient)
+			result, err = sut.UploadWithResult("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should describe the file and its link", func() {
+			Expect(err).To(BeNil())
+			Expect(*result).To(Equal(uploader.Result{
+				URL:            "https://www.dropbox.com/s/abc/example-2016-01-02.png?dl=0",
+				DirectURL:      "https://www.dropbox.com/s/abc/example-2016-01-02.png?raw=1",
+				Path:           "/Failures/example-2016-01-02.png",
+				Rev:            "a1c10ce0dd78",
+				Size:           67,
+				ServerModified: serverModified,
+				ContentHash:    "76b3367eda9b4a14d5cf5fbc39022e6f8bbe110dfd6a3319fd2a586b44ff6ca7",
+				Visibility:     "team_only",
+				Expires:        &expires,
+				MIMEType:       "image/png",
+				Width:          1,
+				Height:         1,
+			}))
+		})
+
+		It("Should leave expires out of the JSON of a link that never expires", func() {
+			data, err := json.Marshal(&uploader.Result{URL: "https://www.dropbox.com/s/abc/example-2016-01-02.png?dl=0"})
+			Expect(err).To(BeNil())
+			Expect(string(data)).NotTo(ContainSubstring("expires"))
+		})
+	})
 })
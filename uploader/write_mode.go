@@ -0,0 +1,56 @@
+package uploader
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+)
+
+// RevConflictError is returned when an upload in "update:<rev>" mode
+// finds the file at the path is no longer at that rev
+type RevConflictError struct {
+	Path string
+	Rev  string
+	Err  error
+}
+
+func (err *RevConflictError) Error() string {
+	return fmt.Sprintf("%v is no longer at rev %v: %v", err.Path, err.Rev, err.Err)
+}
+
+func (err *RevConflictError) Unwrap() error {
+	return err.Err
+}
+
+// ParseWriteMode converts "add", "overwrite" or "update:<rev>" into a
+// dropbox write mode. An empty string means "overwrite"
+func ParseWriteMode(value string) (*files.WriteMode, error) {
+	switch {
+	case value == "", value == "overwrite":
+		return &files.WriteMode{Tag: "overwrite"}, nil
+	case value == "add":
+		return &files.WriteMode{Tag: "add"}, nil
+	case strings.HasPrefix(value, "update:"):
+		rev := strings.TrimPrefix(value, "update:")
+		if rev == "" {
+			return nil, fmt.Errorf("The update write mode requires a rev, as in update:<rev>")
+		}
+		return &files.WriteMode{Tag: "update", Update: rev}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown write mode: %v, expected add, overwrite or update:<rev>", value)
+}
+
+// uploadError decodes an error from uploading with commitInfo. A path
+// conflict in update mode means the file moved on from the expected rev
+func uploadError(err error, commitInfo *files.CommitInfo) error {
+	err = decodeError(err, commitInfo.Path)
+
+	var pathConflictError *PathConflictError
+	if commitInfo.Mode.Tag != "update" || !errors.As(err, &pathConflictError) {
+		return err
+	}
+	return &RevConflictError{Path: commitInfo.Path, Rev: commitInfo.Mode.Update, Err: pathConflictError.Err}
+}
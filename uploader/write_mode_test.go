@@ -0,0 +1,140 @@
+package uploader_test
+
+import (
+	"github.com/dropbox/dropbox-sdk-go-unofficial/files"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/sharing"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/uploader"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Write modes", func() {
+	var sut uploader.Uploader
+	var fakeClient *FakeClient
+	var result *uploader.Result
+	var err error
+
+	BeforeEach(func() {
+		fileLinkMetadata := &sharing.FileLinkMetadata{Url: "https://dropbox.biz/failures/example-2016-01-02.png"}
+
+		fakeClient = NewFakeClient()
+		fakeClient.UploadSpy.ReturnsFileMetadata = &files.FileMetadata{
+			PathLower:   "/failures/example-2016-01-02 (1).png",
+			PathDisplay: "/failures/Example-2016-01-02 (1).png",
+		}
+		fakeClient.CreateSharedLinkWithSettingsSpy.ReturnsSharedLinkMetadata = &sharing.SharedLinkMetadata{File: fileLinkMetadata}
+	})
+
+	Describe("ParseWriteMode", func() {
+		It("Should default to overwrite", func() {
+			writeMode, err := uploader.ParseWriteMode("")
+			Expect(err).To(BeNil())
+			Expect(writeMode.Tag).To(Equal("overwrite"))
+		})
+
+		It("Should parse add", func() {
+			writeMode, err := uploader.ParseWriteMode("add")
+			Expect(err).To(BeNil())
+			Expect(writeMode.Tag).To(Equal("add"))
+		})
+
+		It("Should parse update with a rev", func() {
+			writeMode, err := uploader.ParseWriteMode("update:a1c10ce0dd78")
+			Expect(err).To(BeNil())
+			Expect(writeMode.Tag).To(Equal("update"))
+			Expect(writeMode.Update).To(Equal("a1c10ce0dd78"))
+		})
+
+		It("Should reject update without a rev", func() {
+			_, err := uploader.ParseWriteMode("update:")
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Should reject an unknown mode", func() {
+			_, err := uploader.ParseWriteMode("append")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("when uploading in add mode with autorename", func() {
+		BeforeEach(func() {
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{WriteMode: "add", Autorename: true})
+			result, err = sut.UploadWithResult("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have called fakeClient.Upload with the write mode and autorename", func() {
+			commitInfo := fakeClient.UploadSpy.LastCalledWithCommitInfo
+			Expect(commitInfo.Mode.Tag).To(Equal("add"))
+			Expect(commitInfo.Autorename).To(BeTrue())
+		})
+
+		It("Should have shared the autorenamed file", func() {
+			spy := fakeClient.CreateSharedLinkWithSettingsSpy
+			Expect(spy.LastCalledWith.Path).To(Equal("/failures/example-2016-01-02 (1).png"))
+		})
+
+		It("Should return the path dropbox used", func() {
+			Expect(result.Path).To(Equal("/failures/Example-2016-01-02 (1).png"))
+			Expect(result.URL).To(Equal("https://dropbox.biz/failures/example-2016-01-02.png"))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("when uploading with an unknown write mode", func() {
+		BeforeEach(func() {
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{WriteMode: "append"})
+			result, err = sut.UploadWithResult("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should not have uploaded anything", func() {
+			Expect(fakeClient.UploadSpy.CallCount).To(Equal(0))
+		})
+
+		It("Should have an error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("when an update conflicts with a newer rev", func() {
+		BeforeEach(func() {
+			writeError := &files.WriteError{Tag: "conflict", Conflict: &files.WriteConflictError{Tag: "file"}}
+			uploadError := &files.UploadError{Tag: "path", Path: files.NewUploadWriteFailed(writeError, "")}
+			fakeClient.UploadSpy.ReturnsError = FakeAPIError{EndpointError: uploadError}
+
+			sut = uploader.NewWithClientAndOptions(fakeClient, uploader.Options{WriteMode: "update:a1c10ce0dd78"})
+			result, err = sut.UploadWithResult("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should have a RevConflictError", func() {
+			revConflictError, ok := err.(*uploader.RevConflictError)
+			Expect(ok).To(BeTrue())
+			Expect(revConflictError.Path).To(Equal("/failures/example-2016-01-02.png"))
+			Expect(revConflictError.Rev).To(Equal("a1c10ce0dd78"))
+		})
+
+		It("Should have a nil result", func() {
+			Expect(result).To(BeNil())
+		})
+	})
+
+	Describe("when an overwrite conflicts", func() {
+		BeforeEach(func() {
+			writeError := &files.WriteError{Tag: "conflict", Conflict: &files.WriteConflictError{Tag: "folder"}}
+			uploadError := &files.UploadError{Tag: "path", Path: files.NewUploadWriteFailed(writeError, "")}
+			fakeClient.UploadSpy.ReturnsError = FakeAPIError{EndpointError: uploadError}
+
+			sut = uploader.NewWithClient(fakeClient)
+			result, err = sut.UploadWithResult("/failures/example-2016-01-02.png", sampleImage())
+		})
+
+		It("Should not have a RevConflictError", func() {
+			_, ok := err.(*uploader.RevConflictError)
+			Expect(ok).To(BeFalse())
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+)
+
+// DefaultSignatureHeader carries the body's signature when a Secret is
+// given
+const DefaultSignatureHeader = "X-Signature-256"
+
+// DefaultBodyTemplate renders the notification as a JSON object
+const DefaultBodyTemplate = `{
+	"title": {{json .Title}},
+	"text": {{json .Text}},
+	"url": {{json .URL}},
+	"image_url": {{json .ImageURL}},
+	"filename": {{json .Filename}},
+	"timestamp": {{json .Timestamp}},
+	"facts": {{json .Facts}}
+}`
+
+// Webhook sends notifications as JSON to any URL
+type Webhook interface {
+	// Notify sends the body rendered from the notification
+	notifier.Notifier
+}
+
+// Options configures the behavior of a Webhook instance
+type Options struct {
+	notifier.HTTPOptions
+
+	// Method defaults to POST
+	Method string
+
+	// BodyTemplate is a Go text/template of the JSON body, executed
+	// with the notifier.Notification. The json func encodes a value as
+	// JSON. It defaults to DefaultBodyTemplate
+	BodyTemplate string
+
+	// Headers are set on every request, after the Content-Type
+	Headers map[string]string
+
+	// Secret signs the body with HMAC-SHA256 when it is not empty. The
+	// signature is sent as sha256=<hex> in the SignatureHeader
+	Secret string
+
+	// SignatureHeader defaults to DefaultSignatureHeader
+	SignatureHeader string
+}
+
+type webhook struct {
+	uri     string
+	tmpl    *template.Template
+	options Options
+}
+
+// New constructs a new webhook instance that sends to the uri. It fails
+// when the body template does not parse
+func New(uri string, options Options) (Webhook, error) {
+	options.HTTPOptions = options.HTTPOptions.WithDefaults()
+	if options.Method == "" {
+		options.Method = "POST"
+	}
+	if options.BodyTemplate == "" {
+		options.BodyTemplate = DefaultBodyTemplate
+	}
+	if options.SignatureHeader == "" {
+		options.SignatureHeader = DefaultSignatureHeader
+	}
+
+	funcs := template.FuncMap{"json": toJSON}
+	tmpl, err := template.New("body").Funcs(funcs).Option("missingkey=error").Parse(options.BodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook{uri, tmpl, options}, nil
+}
+
+func (webhook *webhook) Notify(notification *notifier.Notification) error {
+	var body bytes.Buffer
+	err := webhook.tmpl.Execute(&body, notification)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(body.Bytes()) {
+		return fmt.Errorf("Webhook body template did not render JSON: %v", body.String())
+	}
+
+	signature := ""
+	if webhook.options.Secret != "" {
+		signature = Sign(webhook.options.Secret, body.Bytes())
+	}
+
+	_, err = webhook.options.Send(func() (*http.Request, error) {
+		request, err := http.NewRequest(webhook.options.Method, webhook.uri, bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", "application/json")
+		for name, value := range webhook.options.Headers {
+			request.Header.Set(name, value)
+		}
+		if signature != "" {
+			request.Header.Set(webhook.options.SignatureHeader, signature)
+		}
+		return request, nil
+	})
+	return err
+}
+
+// Sign returns the HMAC-SHA256 of the body with the secret, formatted as
+// sha256=<hex> for a receiver to compare against
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
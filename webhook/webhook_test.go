@@ -0,0 +1,132 @@
+package webhook_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/notifier"
+	"github.com/octoblu/image-upload-to-dropbox-and-post-to-slack/webhook"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	var sut webhook.Webhook
+	var server *httptest.Server
+	var lastRequest *http.Request
+	var lastRequestBody string
+	var options webhook.Options
+	var newErr error
+	var err error
+
+	notification := &notifier.Notification{
+		Title:     "example.png",
+		URL:       "https://dropbox.biz/example.png",
+		ImageURL:  "https://dropbox.biz/example.png?raw=1",
+		Filename:  "example.png",
+		Facts:     []notifier.Fact{{Name: "Job", Value: "ios-smoke"}},
+		Timestamp: time.Date(2016, 7, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	BeforeEach(func() {
+		options = webhook.Options{}
+		lastRequest = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			lastRequest = r
+			lastRequestBody = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		sut, newErr = webhook.New(server.URL+"/hooks/uploads", options)
+		if newErr == nil {
+			err = sut.Notify(notification)
+		}
+	})
+
+	Describe("with the default options", func() {
+		It("Should have posted the notification as JSON", func() {
+			Expect(lastRequest.Method).To(Equal("POST"))
+			Expect(lastRequest.URL.Path).To(Equal("/hooks/uploads"))
+			Expect(lastRequest.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(lastRequestBody).To(MatchJSON(`{
+				"title": "example.png",
+				"text": "",
+				"url": "https://dropbox.biz/example.png",
+				"image_url": "https://dropbox.biz/example.png?raw=1",
+				"filename": "example.png",
+				"timestamp": "2016-07-01T12:00:00Z",
+				"facts": [{"Name": "Job", "Value": "ios-smoke"}]
+			}`))
+		})
+
+		It("Should not have signed the body", func() {
+			Expect(lastRequest.Header.Get("X-Signature-256")).To(Equal(""))
+		})
+
+		It("Should not have an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("with a body template, headers and a secret", func() {
+		BeforeEach(func() {
+			options.BodyTemplate = `{"event": "upload", "link": {{json .URL}}}`
+			options.Headers = map[string]string{"Authorization": "Bearer tool-token"}
+			options.Secret = "It's a Secret to Everybody"
+			options.Method = "PUT"
+		})
+
+		It("Should have sent the rendered body", func() {
+			Expect(lastRequest.Method).To(Equal("PUT"))
+			Expect(lastRequestBody).To(Equal(`{"event": "upload", "link": "https://dropbox.biz/example.png"}`))
+		})
+
+		It("Should have set the headers", func() {
+			Expect(lastRequest.Header.Get("Authorization")).To(Equal("Bearer tool-token"))
+		})
+
+		It("Should have signed the body", func() {
+			Expect(lastRequest.Header.Get("X-Signature-256")).To(Equal(webhook.Sign("It's a Secret to Everybody", []byte(lastRequestBody))))
+		})
+	})
+
+	Describe("when the body template does not render JSON", func() {
+		BeforeEach(func() {
+			options.BodyTemplate = `link: {{.URL}}`
+		})
+
+		It("Should not have sent anything", func() {
+			Expect(lastRequest).To(BeNil())
+		})
+
+		It("Should have an error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("when the body template does not parse", func() {
+		BeforeEach(func() {
+			options.BodyTemplate = `{{.URL`
+		})
+
+		It("Should have an error from New", func() {
+			Expect(newErr).NotTo(BeNil())
+		})
+	})
+})
+
+var _ = Describe("Sign", func() {
+	It("Should match the HMAC-SHA256 of the body", func() {
+		Expect(webhook.Sign("It's a Secret to Everybody", []byte("Hello, World!"))).To(Equal("sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"))
+	})
+})